@@ -0,0 +1,177 @@
+package asff
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"io"
+	"os"
+	"strings"
+)
+
+// envelope is any of the JSON values containing findings in ASFF
+type envelope struct {
+	// Findings of the output of 'aws securityhub get-findings'
+	Findings []json.RawMessage
+	// EventBridge event of 'Security Hub Findings - Imported'
+	DetailType string `json:"detail-type"`
+	Detail     *struct {
+		Findings []json.RawMessage
+	}
+	// Id of a finding itself
+	Id *string
+}
+
+const importedEventDetailType = "Security Hub Findings - Imported"
+
+// LoadFindings loads the findings in ASFF from the file, or from stdin if the path is '-'.
+// The file may contain the output of 'aws securityhub get-findings', a JSON array or JSON Lines of findings,
+// or EventBridge events of 'Security Hub Findings - Imported'. Gzip-compressed files are also accepted.
+func LoadFindings(path string) ([]types.AwsSecurityFinding, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	findings, err := ReadFindings(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load findings from '%s': %w", path, err)
+	}
+	return findings, nil
+}
+
+// ReadFindings reads the findings in any of the formats accepted by LoadFindings
+func ReadFindings(r io.Reader) ([]types.AwsSecurityFinding, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Gzip magic number
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		b, err = io.ReadAll(gr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ret := make([]types.AwsSecurityFinding, 0)
+	// Decode the values one by one, which also handles JSON Lines
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		findings, err := decodeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, findings...)
+	}
+	for i, f := range ret {
+		err = validateFinding(f)
+		if err != nil && f.Id != nil {
+			return nil, fmt.Errorf("finding #%d '%s': %w", i, *f.Id, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("finding #%d: %w", i, err)
+		}
+	}
+	return ret, nil
+}
+
+// validateFinding checks that the finding has the fields required by the export, which are always
+// in the findings fetched from AWS SecurityHub
+func validateFinding(f types.AwsSecurityFinding) error {
+	fields := []struct {
+		name    string
+		missing bool
+	}{
+		{"Id", f.Id == nil},
+		{"Title", f.Title == nil},
+		{"Region", f.Region == nil},
+		{"AwsAccountId", f.AwsAccountId == nil},
+		{"CreatedAt", f.CreatedAt == nil},
+		{"UpdatedAt", f.UpdatedAt == nil},
+		{"Severity", f.Severity == nil},
+		{"Workflow", f.Workflow == nil},
+		// Findings without resources belong to no project
+		{"Resources", len(f.Resources) == 0},
+	}
+	for _, field := range fields {
+		if field.missing {
+			return fmt.Errorf("required field '%s' is missing", field.name)
+		}
+	}
+	return nil
+}
+
+func decodeValue(value json.RawMessage) ([]types.AwsSecurityFinding, error) {
+	trimmed := strings.TrimSpace(string(value))
+	if strings.HasPrefix(trimmed, "[") {
+		var values []json.RawMessage
+		err := json.Unmarshal(value, &values)
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]types.AwsSecurityFinding, 0)
+		for _, v := range values {
+			findings, err := decodeValue(v)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, findings...)
+		}
+		return ret, nil
+	}
+
+	var e envelope
+	err := json.Unmarshal(value, &e)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case e.Findings != nil:
+		return decodeFindings(e.Findings)
+	case e.Detail != nil:
+		if e.DetailType != importedEventDetailType {
+			return nil, nil
+		}
+		return decodeFindings(e.Detail.Findings)
+	case e.Id != nil:
+		return decodeFindings([]json.RawMessage{value})
+	default:
+		return nil, fmt.Errorf("unknown JSON value: %.100s", trimmed)
+	}
+}
+
+func decodeFindings(values []json.RawMessage) ([]types.AwsSecurityFinding, error) {
+	ret := make([]types.AwsSecurityFinding, 0)
+	for _, v := range values {
+		var f types.AwsSecurityFinding
+		err := json.Unmarshal(v, &f)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, f)
+	}
+	return ret, nil
+}
@@ -0,0 +1,68 @@
+package asff
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const finding1 = `{"SchemaVersion":"2018-10-08","Id":"id-1","Title":"finding 1","AwsAccountId":"111111111111","Region":"ap-northeast-1","CreatedAt":"2023-08-01T00:00:00Z","UpdatedAt":"2023-08-02T00:00:00Z","Severity":{"Label":"HIGH","Normalized":70},"Resources":[{"Id":"arn:aws:s3:::bucket","Type":"AwsS3Bucket","Tags":{"Project":"foo"}}],"Workflow":{"Status":"NEW"}}`
+const finding2 = `{"SchemaVersion":"2018-10-08","Id":"id-2","Title":"finding 2","AwsAccountId":"111111111111","Region":"ap-northeast-1","CreatedAt":"2023-08-01T00:00:00Z","UpdatedAt":"2023-08-02T00:00:00Z","Severity":{"Label":"CRITICAL","Normalized":90},"Resources":[{"Id":"AWS::::Account:111111111111","Type":"AwsAccount"}],"Workflow":{"Status":"NEW"}}`
+
+func TestReadFindings(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"get-findings output", `{"Findings":[` + finding1 + `,` + finding2 + `],"NextToken":""}`},
+		{"array", `[` + finding1 + `,` + finding2 + `]`},
+		{"jsonl", finding1 + "\n" + finding2 + "\n"},
+		{"events", `{"version":"0","id":"event-1","detail-type":"Security Hub Findings - Imported","detail":{"findings":[` + finding1 + `]}}
+{"version":"0","id":"event-2","detail-type":"Security Hub Findings - Imported","detail":{"findings":[` + finding2 + `]}}
+{"version":"0","id":"event-3","detail-type":"Security Hub Insight Results","detail":{"findings":[]}}`},
+	}
+	for _, tt := range tests {
+		findings, err := ReadFindings(strings.NewReader(tt.input))
+		assert.NoError(t, err, tt.name)
+		assert.Len(t, findings, 2, tt.name)
+		assert.Equal(t, "id-1", *findings[0].Id, tt.name)
+		assert.Equal(t, "HIGH", string(findings[0].Severity.Label), tt.name)
+		assert.Equal(t, "id-2", *findings[1].Id, tt.name)
+	}
+}
+
+func TestLoadFindingsGzip(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(finding1))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "findings.json.gz")
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	findings, err := LoadFindings(path)
+	assert.NoError(t, err)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "foo", findings[0].Resources[0].Tags["Project"])
+}
+
+func TestReadFindingsUnknown(t *testing.T) {
+	_, err := ReadFindings(strings.NewReader(`{"foo":"bar"}`))
+	assert.Error(t, err)
+}
+
+func TestReadFindingsMissingField(t *testing.T) {
+	_, err := ReadFindings(strings.NewReader(finding1 + "\n" + strings.Replace(finding2, `"UpdatedAt":"2023-08-02T00:00:00Z",`, "", 1)))
+	assert.EqualError(t, err, "finding #1 'id-2': required field 'UpdatedAt' is missing")
+
+	_, err = ReadFindings(strings.NewReader(strings.Replace(finding1, `"Resources":[{"Id":"arn:aws:s3:::bucket","Type":"AwsS3Bucket","Tags":{"Project":"foo"}}],`, `"Resources":[],`, 1)))
+	assert.EqualError(t, err, "finding #0 'id-1': required field 'Resources' is missing")
+
+	_, err = ReadFindings(strings.NewReader(`{"Findings":[` + strings.Replace(finding1, `"Id":"id-1",`, "", 1) + `]}`))
+	assert.EqualError(t, err, "finding #0: required field 'Id' is missing")
+}
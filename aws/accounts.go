@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"context"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Account is an AWS account whose resources are accessed by assuming the role
+type Account struct {
+	Id         string
+	RoleArn    string
+	ExternalId string
+}
+
+// AccountConfigs holds the AWS configs for each account
+type AccountConfigs struct {
+	defaultConfig awssdk.Config
+	configs       map[string]awssdk.Config
+}
+
+// NewAccountConfigs creates the configs with the credentials of the assumed roles.
+// The credentials are retrieved lazily and cached until they expire.
+func NewAccountConfigs(ctx context.Context, accounts []Account) (*AccountConfigs, error) {
+	defaultConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &AccountConfigs{
+		defaultConfig: defaultConfig,
+		configs:       make(map[string]awssdk.Config, 0),
+	}
+	stsClient := sts.NewFromConfig(defaultConfig)
+	for _, a := range accounts {
+		a := a
+		provider := stscreds.NewAssumeRoleProvider(stsClient, a.RoleArn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "securityhub-exporter"
+			if a.ExternalId != "" {
+				o.ExternalID = &a.ExternalId
+			}
+		})
+		c := defaultConfig.Copy()
+		c.Credentials = awssdk.NewCredentialsCache(provider)
+		ret.configs[a.Id] = c
+	}
+	return ret, nil
+}
+
+// Get returns the config for the account, or the default config if the account has no role to assume
+func (c *AccountConfigs) Get(accountId string) awssdk.Config {
+	if cfg, ok := c.configs[accountId]; ok {
+		return cfg
+	}
+	return c.defaultConfig
+}
+
+// Default returns the config with the default credentials
+func (c *AccountConfigs) Default() awssdk.Config {
+	return c.defaultConfig
+}
+
+// groupArnsByAccount groups the ARNs by the account ID in them
+func groupArnsByAccount(arns []string) map[string][]string {
+	ret := make(map[string][]string, 0)
+	for _, a := range arns {
+		accountId := ""
+		if parsed, err := arn.Parse(a); err == nil {
+			accountId = parsed.AccountID
+		}
+		ret[accountId] = append(ret[accountId], a)
+	}
+	return ret
+}
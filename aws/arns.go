@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"regexp"
+)
+
+// arnRule rewrites the resource part of the ARNs of the service, to fix a malformed ARN
+// or to map a sub-resource to its taggable parent resource
+type arnRule struct {
+	service string
+	pattern *regexp.Regexp
+	// Replacement of the resource part, with the submatches of the pattern
+	replacement string
+	// Whether the rule applies only to the ARNs without the region, such as the S3 bucket ARNs
+	regionless bool
+}
+
+// Rules for the resource IDs emitted by SecurityHub, the first matched rule applies
+var arnRules = []arnRule{
+	// EC2 ARNs without the resource type like 'arn:aws:ec2:<region>:<account>:i-0123456789abcdef0'
+	{service: "ec2", pattern: regexp.MustCompile(`^(i-[0-9a-f]+)$`), replacement: "instance/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(vol-[0-9a-f]+)$`), replacement: "volume/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(sg-[0-9a-f]+)$`), replacement: "security-group/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(eni-[0-9a-f]+)$`), replacement: "network-interface/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(vpc-[0-9a-f]+)$`), replacement: "vpc/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(subnet-[0-9a-f]+)$`), replacement: "subnet/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(acl-[0-9a-f]+)$`), replacement: "network-acl/$1"},
+	{service: "ec2", pattern: regexp.MustCompile(`^(eipalloc-[0-9a-f]+)$`), replacement: "elastic-ip/$1"},
+	// Rules of the security group
+	{service: "ec2", pattern: regexp.MustCompile(`^security-group/(sg-[0-9a-f]+)/.+$`), replacement: "security-group/$1"},
+	// Objects in the bucket
+	{service: "s3", pattern: regexp.MustCompile(`^([^/]+)/.+$`), replacement: "$1", regionless: true},
+	// Container images in the repository
+	{service: "ecr", pattern: regexp.MustCompile(`^repository/(.+)/sha256:[0-9a-f]+$`), replacement: "repository/$1"},
+	// Versions and aliases of the function
+	{service: "lambda", pattern: regexp.MustCompile(`^function:([^:]+):.+$`), replacement: "function:$1"},
+	// Log streams of the log group, or the log group with the trailing ':*'
+	{service: "logs", pattern: regexp.MustCompile(`^log-group:([^:]+):.*$`), replacement: "log-group:$1"},
+	// Indexes and streams of the table
+	{service: "dynamodb", pattern: regexp.MustCompile(`^table/([^/]+)/.+$`), replacement: "table/$1"},
+	// Listeners and listener rules of the load balancer
+	{service: "elasticloadbalancing", pattern: regexp.MustCompile(`^listener(?:-rule)?/(app|net|gwy)/([^/]+)/([^/]+)/.+$`), replacement: "loadbalancer/$1/$2/$3"},
+}
+
+// IsArn returns true if the resource ID is an ARN in any partition
+func IsArn(resourceId string) bool {
+	_, err := arn.Parse(resourceId)
+	return err == nil
+}
+
+// NormalizeArn returns the ARN of the taggable resource for the resource ID in the finding.
+// It returns the resource ID itself and false if it is not an ARN.
+func NormalizeArn(resourceId string) (string, bool) {
+	parsed, err := arn.Parse(resourceId)
+	if err != nil {
+		return resourceId, false
+	}
+	for _, r := range arnRules {
+		if r.service != parsed.Service || (r.regionless && parsed.Region != "") {
+			continue
+		}
+		if r.pattern.MatchString(parsed.Resource) {
+			parsed.Resource = r.pattern.ReplaceAllString(parsed.Resource, r.replacement)
+			break
+		}
+	}
+	return parsed.String(), true
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeArn(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected string
+	}{
+		{"arn:aws:ec2:us-east-1:123456789012:i-0123456789abcdef0", "arn:aws:ec2:us-east-1:123456789012:instance/i-0123456789abcdef0"},
+		{"arn:aws:ec2:us-east-1:123456789012:instance/i-0123456789abcdef0", "arn:aws:ec2:us-east-1:123456789012:instance/i-0123456789abcdef0"},
+		{"arn:aws-cn:ec2:cn-north-1:123456789012:vol-0123", "arn:aws-cn:ec2:cn-north-1:123456789012:volume/vol-0123"},
+		{"arn:aws-us-gov:ec2:us-gov-west-1:123456789012:sg-0123", "arn:aws-us-gov:ec2:us-gov-west-1:123456789012:security-group/sg-0123"},
+		{"arn:aws:ec2:us-east-1:123456789012:security-group/sg-0123/rule/sgr-0456", "arn:aws:ec2:us-east-1:123456789012:security-group/sg-0123"},
+		{"arn:aws:s3:::my-bucket/path/to/object", "arn:aws:s3:::my-bucket"},
+		{"arn:aws:s3:::my-bucket", "arn:aws:s3:::my-bucket"},
+		{"arn:aws:s3:us-east-1:123456789012:accesspoint/my-ap", "arn:aws:s3:us-east-1:123456789012:accesspoint/my-ap"},
+		{"arn:aws:ecr:us-east-1:123456789012:repository/team/app/sha256:abcdef", "arn:aws:ecr:us-east-1:123456789012:repository/team/app"},
+		{"arn:aws:lambda:us-east-1:123456789012:function:my-func:$LATEST", "arn:aws:lambda:us-east-1:123456789012:function:my-func"},
+		{"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/foo:*", "arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/foo"},
+		{"arn:aws:dynamodb:us-east-1:123456789012:table/my-table/stream/2023-01-01T00:00:00.000", "arn:aws:dynamodb:us-east-1:123456789012:table/my-table"},
+		{"arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-lb/50dc6c495c0c9188/f2f7dc8efc522ab2", "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/50dc6c495c0c9188"},
+		{"arn:aws:iam::123456789012:role/my-role", "arn:aws:iam::123456789012:role/my-role"},
+	}
+	for _, tt := range tests {
+		actual, ok := NormalizeArn(tt.id)
+		assert.True(t, ok, tt.id)
+		assert.Equal(t, tt.expected, actual, tt.id)
+	}
+
+	actual, ok := NormalizeArn("AWS::::Account:123456789012")
+	assert.False(t, ok)
+	assert.Equal(t, "AWS::::Account:123456789012", actual)
+}
+
+func TestIsArn(t *testing.T) {
+	assert.True(t, IsArn("arn:aws:s3:::my-bucket"))
+	assert.True(t, IsArn("arn:aws-cn:s3:::my-bucket"))
+	assert.True(t, IsArn("arn:aws-us-gov:iam::123456789012:role/my-role"))
+	assert.False(t, IsArn("AWS::::Account:123456789012"))
+	assert.False(t, IsArn("i-0123456789abcdef0"))
+}
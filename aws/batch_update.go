@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"log"
+)
+
+const noteUpdatedBy = "securityhub-exporter"
+
+// FindingUpdate is a change of the workflow status and the note of a finding
+type FindingUpdate struct {
+	Id             string
+	ProductArn     string
+	WorkflowStatus types.WorkflowStatus
+	Note           string
+}
+
+// UpdateFindings applies the updates by BatchUpdateFindings, which accepts up to 100 findings sharing the same changes.
+// It returns the number of the processed findings.
+func UpdateFindings(ctx context.Context, updates []FindingUpdate) (int, error) {
+	awsConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	client := securityhub.NewFromConfig(awsConfig)
+
+	// Group the findings by the changes
+	type change struct {
+		workflowStatus types.WorkflowStatus
+		note           string
+	}
+	changes := make([]change, 0)
+	change2Ids := make(map[change][]types.AwsSecurityFindingIdentifier, 0)
+	for _, u := range updates {
+		u := u
+		c := change{workflowStatus: u.WorkflowStatus, note: u.Note}
+		if _, ok := change2Ids[c]; !ok {
+			changes = append(changes, c)
+		}
+		change2Ids[c] = append(change2Ids[c], types.AwsSecurityFindingIdentifier{
+			Id:         &u.Id,
+			ProductArn: &u.ProductArn,
+		})
+	}
+
+	processed := 0
+	for _, c := range changes {
+		for _, ids := range chunk(change2Ids[c], 100) {
+			input := securityhub.BatchUpdateFindingsInput{
+				FindingIdentifiers: ids,
+			}
+			if c.workflowStatus != "" {
+				input.Workflow = &types.WorkflowUpdate{Status: c.workflowStatus}
+			}
+			if c.note != "" {
+				note := c.note
+				updatedBy := noteUpdatedBy
+				input.Note = &types.NoteUpdate{Text: &note, UpdatedBy: &updatedBy}
+			}
+			res, err := client.BatchUpdateFindings(ctx, &input)
+			if err != nil {
+				return processed, err
+			}
+			processed += len(res.ProcessedFindings)
+			for _, u := range res.UnprocessedFindings {
+				log.Printf("failed to update finding '%s': %s\n", *u.FindingIdentifier.Id, *u.ErrorMessage)
+			}
+		}
+	}
+
+	if processed < len(updates) {
+		return processed, fmt.Errorf("%d of %d findings were not updated", len(updates)-processed, len(updates))
+	}
+	return processed, nil
+}
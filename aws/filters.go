@@ -0,0 +1,166 @@
+package aws
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"golang.org/x/exp/slices"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter is a condition on a field of the findings, whose available properties depend on the type of the field
+type Filter struct {
+	// Comparison of string and map filters, EQUALS by default
+	Comparison string
+	// Value of string, map, keyword and boolean filters
+	Value string
+	// Key of map filters such as ResourceTags
+	Key string
+	// Start and end of date filters in ISO 8601
+	Start string
+	End   string
+	// Number of days until now of date filters
+	DateRange int32
+	// Comparisons of number filters
+	Eq  *float64
+	Gte *float64
+	Lte *float64
+	// CIDR of IP filters
+	Cidr string
+}
+
+// CreateFindingFilters creates the filters from the regions, product names and severities,
+// and the filters by field name of AwsSecurityFindingFilters such as 'WorkflowStatus'.
+// Only active findings are returned unless the filters contain RecordState.
+func CreateFindingFilters(regions []string, productNames []string, severities []Severity, filters map[string][]Filter) (*types.AwsSecurityFindingFilters, error) {
+	ret := createFindingFilters(regions, productNames, severities)
+
+	v := reflect.ValueOf(ret).Elem()
+	for name, fs := range filters {
+		// Field names are case-insensitive since the config keys are lower-cased
+		field := v.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+		if !field.IsValid() || field.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("unknown filter field '%s'", name)
+		}
+		for _, f := range fs {
+			elem, err := createFilter(field.Type().Elem(), f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter of '%s': %w", name, err)
+			}
+			field.Set(reflect.Append(field, reflect.ValueOf(elem)))
+		}
+	}
+
+	if len(ret.RecordState) == 0 {
+		ret.RecordState = equalsFilters([]string{string(types.RecordStateActive)})
+	}
+
+	return ret, nil
+}
+
+func createFilter(t reflect.Type, f Filter) (interface{}, error) {
+	switch t {
+	case reflect.TypeOf(types.StringFilter{}):
+		comparison := types.StringFilterComparison(strings.ToUpper(f.Comparison))
+		if comparison == "" {
+			comparison = types.StringFilterComparisonEquals
+		}
+		if !slices.Contains(comparison.Values(), comparison) {
+			return nil, fmt.Errorf("unknown comparison '%s', available values are %v", f.Comparison, comparison.Values())
+		}
+		if f.Value == "" {
+			return nil, fmt.Errorf("value is required")
+		}
+		value := f.Value
+		return types.StringFilter{Comparison: comparison, Value: &value}, nil
+
+	case reflect.TypeOf(types.MapFilter{}):
+		comparison := types.MapFilterComparison(strings.ToUpper(f.Comparison))
+		if comparison == "" {
+			comparison = types.MapFilterComparisonEquals
+		}
+		if !slices.Contains(comparison.Values(), comparison) {
+			return nil, fmt.Errorf("unknown comparison '%s', available values are %v", f.Comparison, comparison.Values())
+		}
+		if f.Key == "" {
+			return nil, fmt.Errorf("key is required")
+		}
+		key := f.Key
+		value := f.Value
+		return types.MapFilter{Comparison: comparison, Key: &key, Value: &value}, nil
+
+	case reflect.TypeOf(types.DateFilter{}):
+		ret := types.DateFilter{}
+		if f.DateRange > 0 {
+			ret.DateRange = &types.DateRange{Unit: types.DateRangeUnitDays, Value: f.DateRange}
+		}
+		for _, d := range []struct {
+			value string
+			ptr   **string
+		}{{f.Start, &ret.Start}, {f.End, &ret.End}} {
+			if d.value == "" {
+				continue
+			}
+			if _, err := time.Parse(time.RFC3339, d.value); err != nil {
+				return nil, fmt.Errorf("invalid date '%s': %w", d.value, err)
+			}
+			value := d.value
+			*d.ptr = &value
+		}
+		if ret.DateRange == nil && ret.Start == nil && ret.End == nil {
+			return nil, fmt.Errorf("dateRange, start or end is required")
+		}
+		if ret.DateRange != nil && (ret.Start != nil || ret.End != nil) {
+			return nil, fmt.Errorf("dateRange cannot be used with start or end")
+		}
+		return ret, nil
+
+	case reflect.TypeOf(types.NumberFilter{}):
+		ret := types.NumberFilter{}
+		if f.Eq == nil && f.Gte == nil && f.Lte == nil {
+			return nil, fmt.Errorf("eq, gte or lte is required")
+		}
+		// Zero values are omitted in the request by the SDK, so the filter would be dropped silently
+		for _, v := range []*float64{f.Eq, f.Gte, f.Lte} {
+			if v != nil && *v == 0 {
+				return nil, fmt.Errorf("eq, gte and lte cannot be 0, since the SDK omits zero values from the request")
+			}
+		}
+		if f.Eq != nil {
+			ret.Eq = *f.Eq
+		}
+		if f.Gte != nil {
+			ret.Gte = *f.Gte
+		}
+		if f.Lte != nil {
+			ret.Lte = *f.Lte
+		}
+		return ret, nil
+
+	case reflect.TypeOf(types.BooleanFilter{}):
+		value, err := strconv.ParseBool(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean '%s'", f.Value)
+		}
+		return types.BooleanFilter{Value: value}, nil
+
+	case reflect.TypeOf(types.IpFilter{}):
+		if f.Cidr == "" {
+			return nil, fmt.Errorf("cidr is required")
+		}
+		cidr := f.Cidr
+		return types.IpFilter{Cidr: &cidr}, nil
+
+	case reflect.TypeOf(types.KeywordFilter{}):
+		if f.Value == "" {
+			return nil, fmt.Errorf("value is required")
+		}
+		value := f.Value
+		return types.KeywordFilter{Value: &value}, nil
+	}
+	return nil, fmt.Errorf("unsupported filter type '%s'", t.Name())
+}
@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCreateFindingFilters(t *testing.T) {
+	gte := 70.0
+	filters, err := CreateFindingFilters([]string{"ap-northeast-1", "us-east-1"}, nil, []Severity{HIGH}, map[string][]Filter{
+		"workflowstatus":     {{Comparison: "not_equals", Value: "SUPPRESSED"}},
+		"ComplianceStatus":   {{Value: "FAILED"}},
+		"updatedat":          {{DateRange: 30}},
+		"severitynormalized": {{Gte: &gte}},
+		"resourcetags":       {{Key: "Env", Value: "prod"}},
+		"generatorid":        {{Comparison: "PREFIX", Value: "aws-foundational"}},
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, filters.Region, 2)
+	assert.Equal(t, "us-east-1", *filters.Region[1].Value)
+	assert.Equal(t, "HIGH", *filters.SeverityLabel[0].Value)
+	assert.Equal(t, "ACTIVE", *filters.RecordState[0].Value)
+	assert.Equal(t, types.StringFilterComparisonNotEquals, filters.WorkflowStatus[0].Comparison)
+	assert.Equal(t, types.StringFilterComparisonEquals, filters.ComplianceStatus[0].Comparison)
+	assert.Equal(t, int32(30), filters.UpdatedAt[0].DateRange.Value)
+	assert.Equal(t, 70.0, filters.SeverityNormalized[0].Gte)
+	assert.Equal(t, "Env", *filters.ResourceTags[0].Key)
+	assert.Equal(t, types.StringFilterComparisonPrefix, filters.GeneratorId[0].Comparison)
+}
+
+func TestCreateFindingFiltersRecordState(t *testing.T) {
+	filters, err := CreateFindingFilters(nil, nil, nil, map[string][]Filter{
+		"recordstate": {{Value: "ARCHIVED"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, filters.RecordState, 1)
+	assert.Equal(t, "ARCHIVED", *filters.RecordState[0].Value)
+}
+
+func TestCreateFindingFiltersInvalid(t *testing.T) {
+	zero := 0.0
+	tests := map[string][]Filter{
+		"unknownfield":       {{Value: "foo"}},
+		"workflowstatus":     {{Comparison: "GREATER", Value: "NEW"}},
+		"resourcetags":       {{Value: "prod"}},
+		"updatedat":          {{Start: "yesterday"}},
+		"createdat":          {{}},
+		"sample":             {{Value: "maybe"}},
+		"networksourceipv4":  {{}},
+		"severitynormalized": {{Gte: &zero}},
+	}
+	for name, fs := range tests {
+		_, err := CreateFindingFilters(nil, nil, nil, map[string][]Filter{name: fs})
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"sort"
+)
+
+// Insight is a custom insight of SecurityHub with its results and findings
+type Insight struct {
+	Arn              string
+	Name             string
+	GroupByAttribute string
+	Results          []InsightResult
+	Findings         []types.AwsSecurityFinding
+}
+
+// InsightResult is the number of the findings for a value of the group-by attribute
+type InsightResult struct {
+	Value string
+	Count int
+}
+
+// GetInsights returns the insights with the ARNs, with the findings fetched by the filters of each insight
+func GetInsights(ctx context.Context, arns []string) ([]Insight, error) {
+	if len(arns) == 0 {
+		return nil, nil
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := securityhub.NewFromConfig(awsConfig)
+
+	arn2Insight := make(map[string]types.Insight, 0)
+	input := securityhub.GetInsightsInput{
+		InsightArns: arns,
+		MaxResults:  100,
+	}
+	for {
+		res, err := client.GetInsights(ctx, &input)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range res.Insights {
+			arn2Insight[*i.InsightArn] = i
+		}
+		if res.NextToken == nil || *res.NextToken == "" {
+			break
+		}
+		input.NextToken = res.NextToken
+	}
+
+	ret := make([]Insight, 0)
+	for _, arn := range arns {
+		i, ok := arn2Insight[arn]
+		if !ok {
+			return nil, fmt.Errorf("insight '%s' not found", arn)
+		}
+
+		results, err := client.GetInsightResults(ctx, &securityhub.GetInsightResultsInput{
+			InsightArn: &arn,
+		})
+		if err != nil {
+			return nil, err
+		}
+		values := make([]InsightResult, 0)
+		for _, v := range results.InsightResults.ResultValues {
+			values = append(values, InsightResult{Value: *v.GroupByAttributeValue, Count: int(v.Count)})
+		}
+		sort.SliceStable(values, func(i, j int) bool {
+			return values[i].Count > values[j].Count
+		})
+
+		findings, err := getAllFindings(ctx, client, securityhub.GetFindingsInput{
+			Filters:    i.Filters,
+			MaxResults: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, Insight{
+			Arn:              arn,
+			Name:             *i.Name,
+			GroupByAttribute: *i.GroupByAttribute,
+			Results:          values,
+			Findings:         findings,
+		})
+	}
+	return ret, nil
+}
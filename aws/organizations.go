@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"golang.org/x/sync/errgroup"
+	"sync"
+)
+
+// Concurrency of the requests to AWS Organizations, which has a low rate limit
+const organizationsConcurrency = 4
+
+// OrganizationAccount is an account in AWS Organizations
+type OrganizationAccount struct {
+	Id   string
+	Name string
+	// Names of the root and the organizational units containing the account, joined by '/'
+	OuPath string
+	Tags   map[string]string
+}
+
+// GetOrganizationAccounts returns the accounts in the organization by account ID.
+// It must be called with the credentials of the management account or a delegated administrator.
+func GetOrganizationAccounts(ctx context.Context) (map[string]OrganizationAccount, error) {
+	awsConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := organizations.NewFromConfig(awsConfig)
+
+	ret := make(map[string]OrganizationAccount, 0)
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Accounts {
+			id := awssdk.ToString(a.Id)
+			ret[id] = OrganizationAccount{Id: id, Name: awssdk.ToString(a.Name), Tags: make(map[string]string, 0)}
+		}
+	}
+
+	account2OuPath, err := getOuPaths(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	errs, ctx := errgroup.WithContext(ctx)
+	errs.SetLimit(organizationsConcurrency)
+	var mu sync.Mutex
+	for id := range ret {
+		id := id
+		errs.Go(func() error {
+			tags := make(map[string]string, 0)
+			paginator := organizations.NewListTagsForResourcePaginator(client, &organizations.ListTagsForResourceInput{
+				ResourceId: &id,
+			})
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(ctx)
+				if err != nil {
+					return err
+				}
+				for _, t := range page.Tags {
+					tags[awssdk.ToString(t.Key)] = awssdk.ToString(t.Value)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			a := ret[id]
+			a.Tags = tags
+			a.OuPath = account2OuPath[id]
+			ret[id] = a
+			return nil
+		})
+	}
+	err = errs.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// getOuPaths walks the tree of the organizational units from the roots and returns the OU paths by account ID
+func getOuPaths(ctx context.Context, client *organizations.Client) (map[string]string, error) {
+	ret := make(map[string]string, 0)
+
+	var walk func(parentId string, path string) error
+	walk = func(parentId string, path string) error {
+		accounts := organizations.NewListAccountsForParentPaginator(client, &organizations.ListAccountsForParentInput{
+			ParentId: &parentId,
+		})
+		for accounts.HasMorePages() {
+			page, err := accounts.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, a := range page.Accounts {
+				ret[awssdk.ToString(a.Id)] = path
+			}
+		}
+
+		ous := organizations.NewListOrganizationalUnitsForParentPaginator(client, &organizations.ListOrganizationalUnitsForParentInput{
+			ParentId: &parentId,
+		})
+		for ous.HasMorePages() {
+			page, err := ous.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, ou := range page.OrganizationalUnits {
+				err = walk(awssdk.ToString(ou.Id), path+"/"+awssdk.ToString(ou.Name))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	roots := organizations.NewListRootsPaginator(client, &organizations.ListRootsInput{})
+	for roots.HasMorePages() {
+		page, err := roots.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.Roots {
+			err = walk(awssdk.ToString(r.Id), rootName(r))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+func rootName(r orgTypes.Root) string {
+	if r.Name != nil {
+		return *r.Name
+	}
+	return "Root"
+}
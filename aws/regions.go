@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// Regions where the resources of the global services are tagged, by partition
+var globalServiceRegions = map[string]string{
+	"aws":        "us-east-1",
+	"aws-cn":     "cn-north-1",
+	"aws-us-gov": "us-gov-west-1",
+}
+
+// Services whose ARNs have no region, and whose resources are tagged in the region of the partition
+var globalServices = map[string]bool{
+	"iam":           true,
+	"cloudfront":    true,
+	"route53":       true,
+	"waf":           true,
+	"organizations": true,
+	"shield":        true,
+}
+
+// resourceRegion returns the region where the Resource Groups Tagging API returns the resource.
+// The region hint, such as the region of the finding resource, is used for the regional resources
+// without the region in their ARNs like S3 buckets.
+func resourceRegion(resourceArn string, hint string, defaultRegion string) string {
+	parsed, err := arn.Parse(resourceArn)
+	if err != nil {
+		return defaultRegion
+	}
+	if parsed.Region != "" {
+		return parsed.Region
+	}
+	if globalServices[parsed.Service] {
+		if r, ok := globalServiceRegions[parsed.Partition]; ok {
+			return r
+		}
+	}
+	// Global Accelerator is tagged only in us-west-2
+	if parsed.Service == "globalaccelerator" {
+		return "us-west-2"
+	}
+	if hint != "" {
+		return hint
+	}
+	return defaultRegion
+}
+
+// groupArnsByRegion groups the ARNs by the region where their tags are looked up
+func groupArnsByRegion(arns []string, regionHints map[string]string, defaultRegion string) map[string][]string {
+	ret := make(map[string][]string, 0)
+	for _, a := range arns {
+		r := resourceRegion(a, regionHints[a], defaultRegion)
+		ret[r] = append(ret[r], a)
+	}
+	return ret
+}
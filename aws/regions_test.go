@@ -0,0 +1,26 @@
+package aws
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResourceRegion(t *testing.T) {
+	tests := []struct {
+		arn    string
+		hint   string
+		region string
+	}{
+		{"arn:aws:ec2:ap-northeast-1:111111111111:instance/i-0123456789abcdef0", "us-east-1", "ap-northeast-1"},
+		{"arn:aws:iam::111111111111:role/role", "ap-northeast-1", "us-east-1"},
+		{"arn:aws-cn:iam::111111111111:role/role", "", "cn-north-1"},
+		{"arn:aws:cloudfront::111111111111:distribution/EDFDVBD6EXAMPLE", "", "us-east-1"},
+		{"arn:aws:globalaccelerator::111111111111:accelerator/1234abcd", "", "us-west-2"},
+		{"arn:aws:s3:::bucket", "eu-west-1", "eu-west-1"},
+		{"arn:aws:s3:::bucket", "", "ap-northeast-1"},
+		{"not-an-arn", "eu-west-1", "ap-northeast-1"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.region, resourceRegion(tt.arn, tt.hint, "ap-northeast-1"), tt.arn)
+	}
+}
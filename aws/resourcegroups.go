@@ -2,24 +2,45 @@ package aws
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
 	"golang.org/x/sync/errgroup"
 )
 
-func GetResourcesTags(ctx context.Context, resourceIds []string) (ResourceID2Tags, error) {
-	cfg, _ := config.LoadDefaultConfig(ctx)
-	client := resourcegroupstaggingapi.NewFromConfig(cfg)
+// GetResourcesTags returns the tags of the resources.
+// The resources in the accounts are looked up by assuming the role of the owning account,
+// and in the region of each resource since the tagging API only returns the resources in its own region.
+// The region hints by ARN are used for the resources without the region in their ARNs.
+func GetResourcesTags(ctx context.Context, resourceIds []string, accounts []Account, regionHints map[string]string) (ResourceID2Tags, error) {
+	configs, err := NewAccountConfigs(ctx, accounts)
+	if err != nil {
+		return nil, err
+	}
 
-	resourceIdChunks := chunk(resourceIds, 100)
+	type request struct {
+		client *resourcegroupstaggingapi.Client
+		arns   []string
+	}
+	requests := make([]request, 0)
+	for accountId, accountArns := range groupArnsByAccount(resourceIds) {
+		cfg := configs.Get(accountId)
+		for region, arns := range groupArnsByRegion(accountArns, regionHints, cfg.Region) {
+			client := resourcegroupstaggingapi.NewFromConfig(cfg, func(o *resourcegroupstaggingapi.Options) {
+				o.Region = region
+			})
+			for _, c := range chunk(arns, 100) {
+				requests = append(requests, request{client: client, arns: c})
+			}
+		}
+	}
 
 	errs, ctx := errgroup.WithContext(ctx)
 
-	ch := make(chan []types.ResourceTagMapping, len(resourceIdChunks))
+	ch := make(chan []types.ResourceTagMapping, len(requests))
 
-	for _, chunk := range resourceIdChunks {
-		chunk := chunk
+	for _, req := range requests {
+		client := req.client
+		chunk := req.arns
 		errs.Go(func() error {
 			input := resourcegroupstaggingapi.GetResourcesInput{
 				ResourceARNList: chunk,
@@ -32,13 +53,13 @@ func GetResourcesTags(ctx context.Context, resourceIds []string) (ResourceID2Tag
 			return nil
 		})
 	}
-	err := errs.Wait()
+	err = errs.Wait()
 	if err != nil {
 		return nil, err
 	}
 
 	result := make(ResourceID2Tags, 0)
-	for i := 0; i < len(resourceIdChunks); i++ {
+	for i := 0; i < len(requests); i++ {
 		mappings := <-ch
 		for _, m := range mappings {
 			result[*m.ResourceARN] = m.Tags
@@ -10,7 +10,9 @@ import (
 
 func TestGetResourceTags(t *testing.T) {
 	ctx := context.Background()
-	findings, err := GetFindings(ctx, []string{"ap-northeast-1"}, []string{"Security Hub"}, []Severity{HIGH})
+	filters, err := CreateFindingFilters([]string{"ap-northeast-1"}, []string{"Security Hub"}, []Severity{HIGH}, nil)
+	assert.NoError(t, err)
+	findings, err := GetFindings(ctx, filters)
 	idSet := mapset.NewSet[string]()
 	for _, f := range findings {
 		for _, r := range f.Resources {
@@ -21,7 +23,7 @@ func TestGetResourceTags(t *testing.T) {
 			idSet.Add(*r.Id)
 		}
 	}
-	mappings, err := GetResourcesTags(ctx, idSet.ToSlice())
+	mappings, err := GetResourcesTags(ctx, idSet.ToSlice(), nil, nil)
 
 	arnSet := mapset.NewSet[string]()
 
@@ -43,3 +45,15 @@ func TestChunk(t *testing.T) {
 	result := chunk(ary, 20)
 	assert.True(t, len(result) == 5)
 }
+
+func TestGroupArnsByAccount(t *testing.T) {
+	result := groupArnsByAccount([]string{
+		"arn:aws:ec2:ap-northeast-1:111111111111:instance/i-0123456789abcdef0",
+		"arn:aws:s3:::bucket",
+		"arn:aws:iam::222222222222:role/role",
+		"arn:aws:ec2:ap-northeast-1:111111111111:volume/vol-0123456789abcdef0",
+	})
+	assert.Len(t, result["111111111111"], 2)
+	assert.Len(t, result["222222222222"], 1)
+	assert.Len(t, result[""], 1)
+}
@@ -7,7 +7,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
 )
 
-func GetFindings(ctx context.Context, regions []string, productNames []string, severities []Severity) ([]types.AwsSecurityFinding, error) {
+// GetFindings returns the findings matching the filters created by CreateFindingFilters
+func GetFindings(ctx context.Context, filters *types.AwsSecurityFindingFilters) ([]types.AwsSecurityFinding, error) {
 	awsConfig, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		return nil, err
@@ -15,8 +16,50 @@ func GetFindings(ctx context.Context, regions []string, productNames []string, s
 
 	client := securityhub.NewFromConfig(awsConfig)
 
-	input := createGetFindingsInput(regions, productNames, severities)
+	input := securityhub.GetFindingsInput{
+		Filters:    filters,
+		MaxResults: 100,
+	}
+
+	return getAllFindings(ctx, client, input)
+}
+
+// GetFindingsByIds returns the findings with the IDs regardless of their record states
+func GetFindingsByIds(ctx context.Context, ids []string) ([]types.AwsSecurityFinding, error) {
+	awsConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := securityhub.NewFromConfig(awsConfig)
+
+	ret := make([]types.AwsSecurityFinding, 0)
+	// Security Hub accepts up to 20 values for each filter field
+	for _, c := range chunk(ids, 20) {
+		idFilters := make([]types.StringFilter, len(c))
+		for i := range c {
+			idFilters[i] = types.StringFilter{
+				Comparison: types.StringFilterComparisonEquals,
+				Value:      &c[i],
+			}
+		}
+		input := securityhub.GetFindingsInput{
+			Filters: &types.AwsSecurityFindingFilters{
+				Id: idFilters,
+			},
+			MaxResults: 100,
+		}
+		findings, err := getAllFindings(ctx, client, input)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, findings...)
+	}
+
+	return ret, nil
+}
 
+func getAllFindings(ctx context.Context, client *securityhub.Client, input securityhub.GetFindingsInput) ([]types.AwsSecurityFinding, error) {
 	ret := make([]types.AwsSecurityFinding, 0)
 
 	for {
@@ -38,62 +81,32 @@ func GetFindings(ctx context.Context, regions []string, productNames []string, s
 	return ret, nil
 }
 
-func createGetFindingsInput(regions []string, productNames []string, severities []Severity) securityhub.GetFindingsInput {
-	// Filter by regions
-	var regionFilters []types.StringFilter = nil
-	if len(regions) > 0 {
-		regionFilters = make([]types.StringFilter, len(regions))
-		for i, r := range regions {
-			regionFilters[i] = types.StringFilter{
-				Comparison: types.StringFilterComparisonEquals,
-				Value:      &r,
-			}
-		}
+func createFindingFilters(regions []string, productNames []string, severities []Severity) *types.AwsSecurityFindingFilters {
+	severityStrings := make([]string, len(severities))
+	for i, s := range severities {
+		severityStrings[i] = string(s)
 	}
 
-	// Filter by product names
-	var productNameFilters []types.StringFilter = nil
-	if len(productNames) > 0 {
-		productNameFilters = make([]types.StringFilter, len(productNames))
-		for i, r := range productNames {
-			productNameFilters[i] = types.StringFilter{
-				Comparison: types.StringFilterComparisonEquals,
-				Value:      &r,
-			}
-		}
+	return &types.AwsSecurityFindingFilters{
+		// Filter by regions
+		Region: equalsFilters(regions),
+		// Filter by product names
+		ProductName: equalsFilters(productNames),
+		// Filter by severities
+		SeverityLabel: equalsFilters(severityStrings),
 	}
+}
 
-	// Filter by severities
-	var severityFilters []types.StringFilter = nil
-	if len(severities) > 0 {
-		severityFilters = make([]types.StringFilter, len(severities))
-		for i, s := range severities {
-			str := string(s)
-			severityFilters[i] = types.StringFilter{
-				Comparison: types.StringFilterComparisonEquals,
-				Value:      &str,
-			}
-		}
+func equalsFilters(values []string) []types.StringFilter {
+	if len(values) == 0 {
+		return nil
 	}
-
-	// Filter by record state
-	recordState := "ACTIVE"
-	recordStateFilters := []types.StringFilter{
-		{
+	ret := make([]types.StringFilter, len(values))
+	for i := range values {
+		ret[i] = types.StringFilter{
 			Comparison: types.StringFilterComparisonEquals,
-			Value:      &recordState,
-		},
-	}
-
-	input := securityhub.GetFindingsInput{
-		Filters: &types.AwsSecurityFindingFilters{
-			Region:        regionFilters,
-			ProductName:   productNameFilters,
-			SeverityLabel: severityFilters,
-			RecordState:   recordStateFilters,
-		},
-		MaxResults: 100,
+			Value:      &values[i],
+		}
 	}
-
-	return input
+	return ret
 }
@@ -7,7 +7,9 @@ import (
 )
 
 func TestGetAllFindings(t *testing.T) {
-	findings, err := GetFindings(context.Background(), []string{"ap-northeast-1"}, []string{"Security Hub"}, []Severity{HIGH})
+	filters, err := CreateFindingFilters([]string{"ap-northeast-1"}, []string{"Security Hub"}, []Severity{HIGH}, nil)
+	assert.NoError(t, err)
+	findings, err := GetFindings(context.Background(), filters)
 	assert.NoError(t, err)
 	assert.True(t, len(findings) > 0)
 }
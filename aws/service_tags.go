@@ -0,0 +1,251 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"golang.org/x/sync/errgroup"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Concurrency of the requests to the service APIs
+const serviceTagsConcurrency = 8
+
+// Resource is a resource of a finding whose tags are looked up
+type Resource struct {
+	// Resource ID normalized by NormalizeArn
+	Id string
+	// Resource type in ASFF such as 'AwsS3Bucket'
+	Type string
+	// Region of the resource in the finding
+	Region string
+	// Account ID of the finding
+	AccountId string
+}
+
+// serviceTagsFunc returns the tags of the resource by the API of its service
+type serviceTagsFunc func(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error)
+
+// Functions to get the tags by resource type, for the resources which the Resource Groups Tagging API does not return
+var serviceTagsFuncs = map[string]serviceTagsFunc{
+	"AwsEc2Instance":         getEc2Tags,
+	"AwsEc2Volume":           getEc2Tags,
+	"AwsEc2SecurityGroup":    getEc2Tags,
+	"AwsEc2NetworkInterface": getEc2Tags,
+	"AwsEc2NetworkAcl":       getEc2Tags,
+	"AwsEc2Vpc":              getEc2Tags,
+	"AwsEc2Subnet":           getEc2Tags,
+	"AwsEc2Eip":              getEc2Tags,
+	"AwsIamRole":             getIamRoleTags,
+	"AwsIamUser":             getIamUserTags,
+	"AwsS3Bucket":            getS3BucketTags,
+	"AwsEcrRepository":       getEcrTags,
+	"AwsEcrContainerImage":   getEcrTags,
+	"AwsLambdaFunction":      getLambdaTags,
+	"AwsAccount":             getAccountTags,
+}
+
+// Resource types whose tags are got with the default credentials instead of the role of the resource account,
+// since AWS Organizations can only be called from the management account or a delegated administrator
+var defaultConfigResourceTypes = map[string]bool{
+	"AwsAccount": true,
+}
+
+// GetResourcesTagsByService returns the tags of the resources by the API of the service selected by the resource type.
+// Resources of the other types are ignored, and the failures are logged without stopping the others
+// since the permissions for the service APIs are often partial. The IDs of the failed resources are also returned.
+func GetResourcesTagsByService(ctx context.Context, resources []Resource, accounts []Account) (ResourceID2Tags, []string, error) {
+	configs, err := NewAccountConfigs(ctx, accounts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(ResourceID2Tags, 0)
+	failed := make([]string, 0)
+	var mu sync.Mutex
+	errs, ctx := errgroup.WithContext(ctx)
+	errs.SetLimit(serviceTagsConcurrency)
+	for _, r := range resources {
+		r := r
+		f, ok := serviceTagsFuncs[r.Type]
+		if !ok {
+			continue
+		}
+		cfg := configs.Get(resourceAccountId(r)).Copy()
+		if defaultConfigResourceTypes[r.Type] {
+			cfg = configs.Default().Copy()
+		}
+		if arn.IsARN(r.Id) {
+			cfg.Region = resourceRegion(r.Id, r.Region, cfg.Region)
+		} else if r.Region != "" {
+			cfg.Region = r.Region
+		}
+		errs.Go(func() error {
+			tags, err := f(ctx, cfg, r)
+			if err != nil {
+				log.Printf("failed to get the tags of %s '%s': %v\n", r.Type, r.Id, err)
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, r.Id)
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			result[r.Id] = tags
+			return nil
+		})
+	}
+	err = errs.Wait()
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, failed, nil
+}
+
+// resourceAccountId returns the account ID in the ARN, or the account ID of the finding
+func resourceAccountId(r Resource) string {
+	if parsed, err := arn.Parse(r.Id); err == nil && parsed.AccountID != "" {
+		return parsed.AccountID
+	}
+	return r.AccountId
+}
+
+// resourceName returns the last part of the resource ID such as the instance ID in the ARN,
+// or the account ID in 'AWS::::Account:<id>'
+func resourceName(resourceId string) string {
+	name := resourceId
+	if parsed, err := arn.Parse(resourceId); err == nil {
+		name = parsed.Resource
+	}
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func getEc2Tags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := ec2.NewFromConfig(cfg)
+	ret := make([]types.Tag, 0)
+	paginator := ec2.NewDescribeTagsPaginator(client, &ec2.DescribeTagsInput{
+		Filters: []ec2Types.Filter{{Name: awssdk.String("resource-id"), Values: []string{resourceName(r.Id)}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Tags {
+			ret = append(ret, types.Tag{Key: t.Key, Value: t.Value})
+		}
+	}
+	return ret, nil
+}
+
+func getIamRoleTags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := iam.NewFromConfig(cfg)
+	ret := make([]types.Tag, 0)
+	paginator := iam.NewListRoleTagsPaginator(client, &iam.ListRoleTagsInput{RoleName: awssdk.String(resourceName(r.Id))})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Tags {
+			ret = append(ret, types.Tag{Key: t.Key, Value: t.Value})
+		}
+	}
+	return ret, nil
+}
+
+func getIamUserTags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := iam.NewFromConfig(cfg)
+	ret := make([]types.Tag, 0)
+	paginator := iam.NewListUserTagsPaginator(client, &iam.ListUserTagsInput{UserName: awssdk.String(resourceName(r.Id))})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Tags {
+			ret = append(ret, types.Tag{Key: t.Key, Value: t.Value})
+		}
+	}
+	return ret, nil
+}
+
+func getS3BucketTags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := s3.NewFromConfig(cfg)
+	res, err := client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: awssdk.String(resourceName(r.Id))})
+	// Buckets without tags return the error instead of the empty tag set
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchTagSet" {
+		return []types.Tag{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]types.Tag, 0)
+	for _, t := range res.TagSet {
+		ret = append(ret, types.Tag{Key: t.Key, Value: t.Value})
+	}
+	return ret, nil
+}
+
+// getEcrTags returns the tags of the repository, which is also the repository of the container image
+// since the resource ID is normalized by NormalizeArn
+func getEcrTags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := ecr.NewFromConfig(cfg)
+	res, err := client.ListTagsForResource(ctx, &ecr.ListTagsForResourceInput{ResourceArn: &r.Id})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]types.Tag, 0)
+	for _, t := range res.Tags {
+		ret = append(ret, types.Tag{Key: t.Key, Value: t.Value})
+	}
+	return ret, nil
+}
+
+func getLambdaTags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := lambda.NewFromConfig(cfg)
+	res, err := client.ListTags(ctx, &lambda.ListTagsInput{Resource: &r.Id})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]types.Tag, 0)
+	for k, v := range res.Tags {
+		ret = append(ret, types.Tag{Key: awssdk.String(k), Value: awssdk.String(v)})
+	}
+	return ret, nil
+}
+
+// getAccountTags returns the tags of the account in AWS Organizations, whose resource ID is like 'AWS::::Account:<id>'
+func getAccountTags(ctx context.Context, cfg awssdk.Config, r Resource) ([]types.Tag, error) {
+	client := organizations.NewFromConfig(cfg)
+	ret := make([]types.Tag, 0)
+	paginator := organizations.NewListTagsForResourcePaginator(client, &organizations.ListTagsForResourceInput{
+		ResourceId: awssdk.String(resourceName(r.Id)),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Tags {
+			ret = append(ret, types.Tag{Key: t.Key, Value: t.Value})
+		}
+	}
+	return ret, nil
+}
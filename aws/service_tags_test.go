@@ -0,0 +1,19 @@
+package aws
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResourceName(t *testing.T) {
+	assert.Equal(t, "i-0123456789abcdef0", resourceName("arn:aws:ec2:us-east-1:123456789012:instance/i-0123456789abcdef0"))
+	assert.Equal(t, "my-role", resourceName("arn:aws:iam::123456789012:role/path/to/my-role"))
+	assert.Equal(t, "my-bucket", resourceName("arn:aws:s3:::my-bucket"))
+	assert.Equal(t, "123456789012", resourceName("AWS::::Account:123456789012"))
+	assert.Equal(t, "sg-0123", resourceName("sg-0123"))
+}
+
+func TestResourceAccountId(t *testing.T) {
+	assert.Equal(t, "111111111111", resourceAccountId(Resource{Id: "arn:aws:s3:::bucket", AccountId: "111111111111"}))
+	assert.Equal(t, "222222222222", resourceAccountId(Resource{Id: "arn:aws:iam::222222222222:role/foo", AccountId: "111111111111"}))
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File caches a value in the JSON file, which expires after the TTL
+type File[T any] struct {
+	Path string
+	// Duration for which the cached value is used, never used if zero like the cache of the resource tags
+	TTL time.Duration
+}
+
+// entry is the layout of the cache file
+type entry[T any] struct {
+	SavedAt time.Time
+	Value   T
+}
+
+func NewFile[T any](path string, ttl time.Duration) File[T] {
+	return File[T]{Path: path, TTL: ttl}
+}
+
+// Load returns the cached value, or false if the file does not exist or the value has expired
+func (f File[T]) Load() (T, bool, error) {
+	value, savedAt, ok, err := f.Read()
+	if err != nil || !ok {
+		return value, false, err
+	}
+	if f.Expired(savedAt) {
+		var zero T
+		return zero, false, nil
+	}
+	return value, true, nil
+}
+
+// Read returns the cached value and the time it was saved regardless of the TTL, or false if the file does not exist
+func (f File[T]) Read() (T, time.Time, bool, error) {
+	var e entry[T]
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return e.Value, e.SavedAt, false, nil
+	}
+	if err != nil {
+		return e.Value, e.SavedAt, false, err
+	}
+	err = json.Unmarshal(b, &e)
+	if err != nil {
+		return e.Value, e.SavedAt, false, err
+	}
+	return e.Value, e.SavedAt, true, nil
+}
+
+// Expired returns whether the value saved at the time has expired
+func (f File[T]) Expired(savedAt time.Time) bool {
+	return time.Since(savedAt) > f.TTL
+}
+
+// Save writes the value to the file
+func (f File[T]) Save(value T) error {
+	err := os.MkdirAll(filepath.Dir(f.Path), 0755)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(entry[T]{SavedAt: time.Now(), Value: value})
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it so that a concurrent run never reads a partial file
+	tmp := f.Path + ".tmp"
+	err = os.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "cache.json")
+	f := NewFile[map[string]string](path, time.Hour)
+
+	_, ok, err := f.Load()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	err = f.Save(map[string]string{"foo": "bar"})
+	assert.NoError(t, err)
+
+	v, ok, err := f.Load()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"foo": "bar"}, v)
+}
+
+func TestFileExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	err := os.WriteFile(path, []byte(`{"SavedAt":"2020-01-01T00:00:00Z","Value":"foo"}`), 0644)
+	assert.NoError(t, err)
+
+	_, ok, err := NewFile[string](path, time.Hour).Load()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	v, ok, err := NewFile[string](path, 100*365*24*time.Hour).Load()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", v)
+
+	// Not cached if zero
+	_, ok, err = NewFile[string](path, 0).Load()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// Read even if expired
+	f := NewFile[string](path, 0)
+	v, savedAt, ok, err := f.Read()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", v)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), savedAt)
+	assert.True(t, f.Expired(savedAt))
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, err := OpenStore[[]string](path)
+	assert.NoError(t, err)
+
+	s.Put("a", []string{"foo"}, time.Hour)
+	s.Put("b", nil, time.Hour)
+	s.Put("c", []string{"bar"}, -time.Second)
+
+	v, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"foo"}, v)
+	_, ok = s.Get("b")
+	assert.True(t, ok)
+	_, ok = s.Get("c")
+	assert.False(t, ok)
+	assert.Equal(t, StoreStats{Entries: 3, Expired: 1}, s.Stats())
+
+	err = s.Save()
+	assert.NoError(t, err)
+
+	s, err = OpenStore[[]string](path)
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Entries: 2}, s.Stats())
+	v, ok = s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"foo"}, v)
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store caches the values by key in the JSON file, each of which expires after its own TTL
+type Store[V any] struct {
+	Path    string
+	entries map[string]storeEntry[V]
+}
+
+type storeEntry[V any] struct {
+	ExpiresAt time.Time
+	Value     V
+}
+
+// StoreStats is the number of the entries in the store
+type StoreStats struct {
+	Entries int
+	Expired int
+}
+
+// NewStore returns an empty store saved to the file
+func NewStore[V any](path string) *Store[V] {
+	return &Store[V]{Path: path, entries: make(map[string]storeEntry[V], 0)}
+}
+
+// OpenStore reads the entries from the file, or returns an empty store if the file does not exist
+func OpenStore[V any](path string) (*Store[V], error) {
+	s := NewStore[V](path)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &s.entries)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the value of the key, or false if it does not exist or has expired
+func (s *Store[V]) Get(key string) (V, bool) {
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.ExpiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.Value, true
+}
+
+// Put sets the value of the key, which expires after the TTL
+func (s *Store[V]) Put(key string, value V, ttl time.Duration) {
+	s.entries[key] = storeEntry[V]{ExpiresAt: time.Now().Add(ttl), Value: value}
+}
+
+// Stats returns the number of all the entries and the expired ones
+func (s *Store[V]) Stats() StoreStats {
+	ret := StoreStats{Entries: len(s.entries)}
+	now := time.Now()
+	for _, e := range s.entries {
+		if now.After(e.ExpiresAt) {
+			ret.Expired++
+		}
+	}
+	return ret
+}
+
+// Save writes the entries to the file, dropping the expired ones
+func (s *Store[V]) Save() error {
+	now := time.Now()
+	for k, e := range s.entries {
+		if now.After(e.ExpiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	err := os.MkdirAll(filepath.Dir(s.Path), 0755)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	err = os.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
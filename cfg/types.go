@@ -1,15 +1,117 @@
 package cfg
 
-import "github.com/kota65535/securityhub-exporter/aws"
+import (
+	"github.com/kota65535/securityhub-exporter/aws"
+	"time"
+)
 
 type Config struct {
 	CredentialsPath string
 	FolderId        string
 	Title           string
-	GroupByTag      string
-	Colors          map[aws.Severity]string
-	Severities      []aws.Severity
-	ProductNames    []string
-	Regions         []string
-	IndexSheetName  string
+	// Resource tags by which the findings are grouped, the latter ones are used if the former ones are missing
+	GroupByTag []string
+	// Whether the tag keys are matched case-insensitively
+	IgnoreTagKeyCase bool
+	// Canonical group names by tag value, matched case-insensitively
+	TagValueAliases map[string]string
+	// File of the rules which map the resources without the group tag to the projects
+	OwnershipRulesFile string
+	// CSV file to which the rule matched for each resource without the group tag is written
+	OwnershipReportFile string
+	// Expression of the attributes by which the findings are grouped, the tag of GroupByTag if empty
+	GroupBy string
+	// How the findings with multiple resources are grouped, "finding" or "resource"
+	MultiResourcePolicy string
+	// Friendly names by account ID used for grouping
+	AccountNames map[string]string
+	// Whether the names, the OU paths and the tags of the accounts are fetched from AWS Organizations
+	UseOrganizations bool
+	// Duration for which the accounts fetched from AWS Organizations are cached, not cached if zero
+	OrganizationsCacheTtl time.Duration
+	// Tags of the accounts in AWS Organizations shown as the columns
+	AccountTagColumns []string
+	// Duration for which the resource tags are cached, not cached if zero
+	TagCacheTtl time.Duration
+	// Duration for which the resources not found by the tagging API are cached, TagCacheTtl if zero
+	TagCacheNegativeTtl time.Duration
+	// Directory of the cache files, the user cache directory if empty
+	CacheDir     string
+	Colors       map[aws.Severity]string
+	Severities   []aws.Severity
+	ProductNames []string
+	Regions      []string
+	// Filters by field name of AwsSecurityFindingFilters
+	Filters map[string][]aws.Filter
+	// ARNs of the custom insights exported in addition to the projects
+	Insights       []string
+	IndexSheetName string
+	// How the project sheets are updated, "recreate" or "sync"
+	SheetUpdateMode string
+	// How the rows of resolved findings are handled in sync mode, "delete" or "mark"
+	ResolvedRowAction string
+	// Name of the sheet to which the number of the findings of each project is appended every run, no history if empty
+	HistorySheetName string
+	// Number of the top projects shown in the history chart, 5 if zero
+	HistoryChartProjects int
+	// Security standards whose controls are shown in the control sheets, matched by the part of the standard IDs
+	ControlStandards []string
+	// Columns next to the project sheet columns whose values are owned by users
+	AnnotationColumns []string
+	// Column whose values are imported to the notes of the findings
+	ImportNoteColumn string
+	// Member accounts whose resources are accessed by assuming the roles
+	Accounts []aws.Account
+	// Output targets, Google Sheet if empty
+	Outputs []Output
+	// Columns of the rows of findings in order, the default columns if empty
+	Columns []Column
+	// Whether the column of the remediation is added to the default columns
+	RemediationColumn bool
+	// Whether the collapsed columns of the description and the notes are added to the default columns
+	DescriptionColumns bool
+}
+
+// Types of the output targets
+const (
+	OutputTypeSheet = "sheet"
+	OutputTypeXlsx  = "xlsx"
+	OutputTypeCsv   = "csv"
+	OutputTypeJsonl = "jsonl"
+)
+
+// How the project sheets are updated
+const (
+	SheetUpdateModeRecreate = "recreate"
+	SheetUpdateModeSync     = "sync"
+)
+
+// How the rows of resolved findings are handled in sync mode
+const (
+	ResolvedRowActionDelete = "delete"
+	ResolvedRowActionMark   = "mark"
+)
+
+type Output struct {
+	// Type of the output target
+	Type string
+	// Path of the output file or directory
+	Path string
+	// Whether the CSV output is combined into a single file
+	Combined bool
+}
+
+type Column struct {
+	// Name of the built-in column such as 'Severity'
+	Field string
+	// Field path in the finding such as 'Compliance.SecurityControlId', instead of the built-in column
+	Path string
+	// Header of the column, the name of the field or the path if empty
+	Header string
+	// Whether the value is a timestamp shown only by its date
+	Date bool
+	// Whether the width of the column is fitted to the values
+	AutoResize bool
+	// Whether the column is hidden in a collapsed group
+	Collapsed bool
 }
@@ -0,0 +1,172 @@
+package cfg
+
+import (
+	"fmt"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/flatfile"
+	"github.com/kota65535/securityhub-exporter/group"
+	"github.com/kota65535/securityhub-exporter/report"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Validate checks the config values which cannot be checked by unmarshalling
+func (c Config) Validate() error {
+	_, err := aws.CreateFindingFilters(c.Regions, c.ProductNames, c.Severities, c.Filters)
+	if err != nil {
+		return err
+	}
+	_, err = group.NewGrouper(c.GroupExpression(), c.GroupOptions())
+	if err != nil {
+		return err
+	}
+	columns, err := c.ReportColumns(nil)
+	if err != nil {
+		return err
+	}
+	flat := false
+	for _, o := range c.Outputs {
+		switch o.Type {
+		case OutputTypeSheet:
+		case OutputTypeXlsx, OutputTypeCsv, OutputTypeJsonl:
+			if o.Path == "" {
+				return fmt.Errorf("path of the %s output is required", o.Type)
+			}
+			flat = flat || o.Type != OutputTypeXlsx
+		default:
+			return fmt.Errorf("unknown output type '%s'", o.Type)
+		}
+	}
+	if flat {
+		err = flatfile.CheckColumns(columns)
+		if err != nil {
+			return err
+		}
+	}
+	switch c.SheetUpdateMode {
+	case "", SheetUpdateModeRecreate, SheetUpdateModeSync:
+	default:
+		return fmt.Errorf("unknown sheetUpdateMode '%s'", c.SheetUpdateMode)
+	}
+	// Rows are deleted unless marked, so a typo must not delete the rows users meant to keep
+	switch c.ResolvedRowAction {
+	case "", ResolvedRowActionDelete, ResolvedRowActionMark:
+	default:
+		return fmt.Errorf("unknown resolvedRowAction '%s'", c.ResolvedRowAction)
+	}
+	if c.HistoryChartProjects < 0 || c.HistoryChartProjects > report.MaxHistoryChartProjects {
+		return fmt.Errorf("historyChartProjects must be between 0 and %d", report.MaxHistoryChartProjects)
+	}
+	return nil
+}
+
+// GroupExpression returns the expression by which the findings are grouped
+func (c Config) GroupExpression() string {
+	if c.GroupBy != "" {
+		return c.GroupBy
+	}
+	return "tag:" + strings.Join(c.GroupByTag, "|")
+}
+
+// GroupOptions returns the options of grouping the findings
+func (c Config) GroupOptions() group.Options {
+	return group.Options{
+		AccountNames:        c.AccountNames,
+		IgnoreTagKeyCase:    c.IgnoreTagKeyCase,
+		TagValueAliases:     c.TagValueAliases,
+		MultiResourcePolicy: c.MultiResourcePolicy,
+	}
+}
+
+// CachePath returns the path of the cache file with the name
+func (c Config) CachePath(name string) (string, error) {
+	dir := c.CacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(userDir, "securityhub-exporter")
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// ReportColumns returns the columns of the rows of findings.
+// The remediation is added to the default columns if enabled, followed by the account name and the OU path
+// if AWS Organizations is used and the description and the notes if enabled.
+// The account tag columns are added in any case.
+// The account columns show the accounts in the organization by account ID.
+func (c Config) ReportColumns(accounts map[string]aws.OrganizationAccount) ([]report.Column, error) {
+	ret := make([]report.Column, 0)
+	if len(c.Columns) == 0 {
+		ret = report.DefaultColumns()
+		if c.RemediationColumn {
+			column, _ := report.BuiltinColumn("Remediation", accounts)
+			ret = append(ret, column)
+		}
+		if c.UseOrganizations {
+			for _, name := range []string{"Account Name", "OU Path"} {
+				column, _ := report.BuiltinColumn(name, accounts)
+				ret = append(ret, column)
+			}
+		}
+		if c.DescriptionColumns {
+			for _, name := range []string{"Description", "Notes"} {
+				column, _ := report.BuiltinColumn(name, accounts)
+				ret = append(ret, column)
+			}
+		}
+	}
+	for _, cc := range c.Columns {
+		var column report.Column
+		switch {
+		case cc.Field != "" && cc.Path != "":
+			return nil, fmt.Errorf("either field or path is allowed in column '%s'", cc.Field)
+		case cc.Field != "":
+			var ok bool
+			column, ok = report.BuiltinColumn(cc.Field, accounts)
+			if !ok {
+				return nil, fmt.Errorf("unknown column field '%s'", cc.Field)
+			}
+		case cc.Path != "":
+			var err error
+			column, err = report.NewPathColumn(cc.Path, cc.Path)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("field or path is required in columns")
+		}
+		if cc.Header != "" {
+			column.Name = cc.Header
+		}
+		column.Date = column.Date || cc.Date
+		column.AutoResize = column.AutoResize || cc.AutoResize
+		column.Collapsed = column.Collapsed || cc.Collapsed
+		ret = append(ret, column)
+	}
+	if c.UseOrganizations {
+		ret = append(ret, report.AccountTagColumns(c.AccountTagColumns, accounts)...)
+	}
+
+	headers := make(map[string]bool, 0)
+	hasId := false
+	hasResource := false
+	for _, column := range ret {
+		if headers[column.Name] {
+			return nil, fmt.Errorf("duplicate column header '%s'", column.Name)
+		}
+		headers[column.Name] = true
+		hasId = hasId || column.Field == "ID"
+		hasResource = hasResource || column.Field == "Resource"
+	}
+	// Rows are identified by the finding ID, and also by the resource if a finding is copied for each resource
+	if !hasId {
+		return nil, fmt.Errorf("column of field 'ID' is required")
+	}
+	if c.MultiResourcePolicy == group.MultiResourcePolicyResource && !hasResource {
+		return nil, fmt.Errorf("column of field 'Resource' is required with multiResourcePolicy '%s'", group.MultiResourcePolicyResource)
+	}
+	return ret, nil
+}
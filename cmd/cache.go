@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cache"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	c := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of the resource tags and the accounts.",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Delete the cache files.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheClear()
+		},
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show the number of the cached entries.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheStats()
+		},
+	})
+
+	rootCmd.AddCommand(c)
+}
+
+func runCacheClear() error {
+	loadConfig()
+
+	for _, name := range []string{tagCacheFile, organizationsCacheFile} {
+		path, err := config.CachePath(name)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s\n", path)
+	}
+	return nil
+}
+
+func runCacheStats() error {
+	loadConfig()
+
+	path, err := config.CachePath(tagCacheFile)
+	if err != nil {
+		return err
+	}
+	store, err := cache.OpenStore[[]types.Tag](path)
+	if err != nil {
+		return err
+	}
+	stats := store.Stats()
+	fmt.Printf("Resource tags (%s)\n", path)
+	fmt.Printf("  TTL: %s, negative TTL: %s\n", config.TagCacheTtl, config.TagCacheNegativeTtl)
+	fmt.Printf("  %d entries, %d expired\n", stats.Entries, stats.Expired)
+
+	path, err = config.CachePath(organizationsCacheFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Accounts (%s)\n", path)
+	// Read regardless of the TTL, since the cache is shown even if it has expired
+	file := cache.NewFile[map[string]aws.OrganizationAccount](path, config.OrganizationsCacheTtl)
+	accounts, savedAt, ok, err := file.Read()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("  not cached")
+		return nil
+	}
+	fmt.Printf("  TTL: %s\n", config.OrganizationsCacheTtl)
+	fmt.Printf("  %d accounts, saved at %s, expired: %t\n", len(accounts), savedAt.Format(time.RFC3339), file.Expired(savedAt))
+	return nil
+}
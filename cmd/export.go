@@ -2,24 +2,27 @@ package cmd
 
 import (
 	"context"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
-	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/kota65535/securityhub-exporter/asff"
 	"github.com/kota65535/securityhub-exporter/aws"
 	"github.com/kota65535/securityhub-exporter/cfg"
-	"github.com/kota65535/securityhub-exporter/sheet"
-	"github.com/spf13/viper"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/group"
+	"github.com/kota65535/securityhub-exporter/ownership"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/kota65535/securityhub-exporter/snapshot"
+	"golang.org/x/exp/maps"
 	"log"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-type Project2Findings map[string][]types.AwsSecurityFinding
-
-const noTagSheetName = "(No Tag)"
-
-var configFile string
-var config cfg.Config
+var inputPath string
+var saveSnapshotPath string
+var fromSnapshotPath string
 
 func init() {
 	c := &cobra.Command{
@@ -29,96 +32,192 @@ func init() {
 			return run()
 		},
 	}
-	c.PersistentFlags().StringVarP(&configFile, "config", "c", "config.yml", "config file path")
+	c.Flags().StringVarP(&inputPath, "input", "i", "", "ASFF JSON file to load the findings from instead of AWS SecurityHub, '-' for stdin")
+	c.Flags().StringVar(&saveSnapshotPath, "save-snapshot", "", "file to save the findings with the resource tags to")
+	c.Flags().StringVar(&fromSnapshotPath, "from-snapshot", "", "file saved by --save-snapshot to load the findings from instead of AWS")
+	c.MarkFlagsMutuallyExclusive("input", "from-snapshot")
 
 	rootCmd.AddCommand(c)
 }
 
 func run() error {
-	viper.SetConfigFile(configFile)
-	viper.AutomaticEnv()
-
-	err := viper.ReadInConfig()
-	cobra.CheckErr(err)
-
-	err = viper.Unmarshal(&config)
-	cobra.CheckErr(err)
+	loadConfig()
+	ctx := context.Background()
 
-	log.Println("Fetching findings...")
-	findings, err := getFindingsWithTags(&config)
+	var findings []types.AwsSecurityFinding
+	var insights []aws.Insight
+	var accounts map[string]aws.OrganizationAccount
+	var err error
+	// Unknown for the findings from the ASFF file
+	var fetchedAt time.Time
+	switch {
+	case inputPath != "":
+		log.Printf("Loading findings from '%s'...\n", inputPath)
+		findings, err = asff.LoadFindings(inputPath)
+	case fromSnapshotPath != "":
+		log.Printf("Loading snapshot from '%s'...\n", fromSnapshotPath)
+		var s *snapshot.Snapshot
+		s, err = snapshot.Load(fromSnapshotPath)
+		if err == nil {
+			findings = s.Findings
+			insights = s.Insights
+			accounts = s.Accounts
+			fetchedAt = s.FetchedAt
+			if !s.FetchedAt.IsZero() {
+				log.Printf("Snapshot was taken at %s\n", s.FetchedAt.Format(time.RFC3339))
+			}
+		}
+	default:
+		log.Println("Fetching findings...")
+		fetchedAt = time.Now()
+		findings, err = getFindingsWithTags(&config)
+		if err == nil && len(config.Insights) > 0 {
+			log.Println("Fetching insights...")
+			insights, err = aws.GetInsights(ctx, config.Insights)
+		}
+	}
 	if err != nil {
 		return err
 	}
+	// Findings from the ASFF file and the snapshot are exported without AWS, so the accounts are only those
+	// in the snapshot saved with AWS Organizations
+	switch {
+	case !config.UseOrganizations || accounts != nil:
+	case inputPath != "":
+		log.Println("Skipping AWS Organizations for the findings loaded from the ASFF file")
+	case fromSnapshotPath != "":
+		log.Println("Skipping AWS Organizations since the snapshot has no accounts")
+	default:
+		accounts, err = getOrganizationAccounts(ctx, &config)
+		if err != nil {
+			return err
+		}
+	}
 	log.Printf("Got %d findings\n", len(findings))
+	for _, i := range insights {
+		log.Printf("Got %d findings for insight '%s'\n", len(i.Findings), i.Name)
+	}
+
+	if saveSnapshotPath != "" {
+		log.Printf("Saving snapshot to '%s'...\n", saveSnapshotPath)
+		err = snapshot.Save(saveSnapshotPath, snapshot.Snapshot{
+			FetchedAt: fetchedAt,
+			Version:   version,
+			Findings:  findings,
+			Insights:  insights,
+			Accounts:  accounts,
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	project2findings := groupFindingsByResourceTag(findings, config.GroupByTag)
+	options := config.GroupOptions()
+	options.Accounts = accounts
+	if config.OwnershipRulesFile != "" {
+		options.Overrides, err = applyOwnershipRules(&config, findings, options)
+		if err != nil {
+			return err
+		}
+	}
+	grouper, err := group.NewGrouper(config.GroupExpression(), options)
+	if err != nil {
+		return err
+	}
+	// Passed findings are counted only in the control sheets
+	var controlProject2Findings exporter.Project2Findings
+	if len(config.ControlStandards) > 0 {
+		controlProject2Findings = grouper.Group(findings)
+		active := report.ExcludePassed(findings)
+		log.Printf("Got %d passed findings for the controls\n", len(findings)-len(active))
+		findings = active
+	}
+	project2findings := grouper.Group(findings)
 	log.Printf("Got %d projects\n", len(project2findings))
 	for p, f := range project2findings {
 		log.Printf("  %d findings for '%s'\n", len(f), p)
 	}
 
-	log.Println("Initializing spreadsheet...")
-	client, err := sheet.NewSpreadSheet(config)
-	if err != nil {
-		return err
+	for _, i := range insights {
+		project2findings[exporter.InsightProjectName(i.Name)] = i.Findings
 	}
 
-	log.Println("Deleting existing sheets...")
-	err = client.DeleteAllSheets([]string{config.IndexSheetName})
+	// Columns of the accounts are known only after the accounts are loaded
+	columns, err := config.ReportColumns(accounts)
 	if err != nil {
 		return err
 	}
-
-	log.Println("Updating sheets...")
-	err = client.UpdateSheets(project2findings)
+	exporters, err := newExporters(config, columns)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Updating index sheets...")
-	err = client.UpdateIndexSheet(project2findings)
-	if err != nil {
-		return err
+	metadata := exporter.Metadata{
+		FetchedAt:               fetchedAt,
+		Version:                 version,
+		Insights:                insights,
+		ControlProject2Findings: controlProject2Findings,
+	}
+	for _, e := range exporters {
+		log.Printf("Exporting to %s...\n", e.Name())
+		err = e.Export(ctx, project2findings, metadata)
+		if err != nil {
+			return err
+		}
 	}
 
-	log.Println("Finished! Click the link below to see the result:")
-	log.Println("https://docs.google.com/spreadsheets/d/" + client.Spreadsheet.SpreadsheetId)
+	log.Println("Finished!")
 	return nil
 }
 
 func getFindingsWithTags(config *cfg.Config) ([]types.AwsSecurityFinding, error) {
 	ctx := context.Background()
 
-	findings, err := aws.GetFindings(ctx, config.Regions, config.ProductNames, config.Severities)
+	filters, err := aws.CreateFindingFilters(config.Regions, config.ProductNames, config.Severities, config.Filters)
 	if err != nil {
 		return nil, err
 	}
 
-	resourceIds := mapset.NewSet[string]()
+	findings, err := aws.GetFindings(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make(map[string]aws.Resource, 0)
 	for _, finding := range findings {
 		for _, resource := range finding.Resources {
-			if !isArn(*resource.Id) {
-				log.Printf("resource ID '%s' is not ARN, skip to get the tags.\n", *resource.Id)
+			// Tags in the finding are used as they are
+			if len(resource.Tags) > 0 {
 				continue
 			}
-			arn := fixArn(*resource.Id)
-			if *resource.Id != arn {
-				log.Printf("fixed ARN like resource ID: '%s' -> '%s'", *resource.Id, arn)
+			id, _ := aws.NormalizeArn(*resource.Id)
+			if *resource.Id != id {
+				log.Printf("normalized resource ID: '%s' -> '%s'", *resource.Id, id)
+			}
+			resources[id] = aws.Resource{
+				Id:        id,
+				Type:      awssdk.ToString(resource.Type),
+				Region:    awssdk.ToString(resource.Region),
+				AccountId: awssdk.ToString(finding.AwsAccountId),
 			}
-			resourceIds.Add(arn)
 		}
 	}
 
-	resourceId2Tags, err := aws.GetResourcesTags(ctx, resourceIds.ToSlice())
+	resourceId2Tags, err := getResourcesTags(ctx, config, maps.Values(resources))
+	if err != nil {
+		return nil, err
+	}
 
 	for i := range findings {
 		f := &findings[i]
 		for j := range f.Resources {
 			r := &f.Resources[j]
-			if r.Tags == nil {
-				r.Tags = make(map[string]string, 0)
+			if len(r.Tags) > 0 {
+				continue
 			}
-			for _, tag := range resourceId2Tags[*r.Id] {
+			r.Tags = make(map[string]string, 0)
+			id, _ := aws.NormalizeArn(*r.Id)
+			for _, tag := range resourceId2Tags[id] {
 				r.Tags[*tag.Key] = *tag.Value
 			}
 		}
@@ -127,39 +226,40 @@ func getFindingsWithTags(config *cfg.Config) ([]types.AwsSecurityFinding, error)
 	return findings, nil
 }
 
-func groupFindingsByResourceTag(findings []types.AwsSecurityFinding, tag string) Project2Findings {
-	result := make(map[string][]types.AwsSecurityFinding, 0)
-	for _, f := range findings {
-		for _, r := range f.Resources {
-			if v, ok := r.Tags[tag]; ok {
-				if _, ok := result[v]; !ok {
-					result[v] = make([]types.AwsSecurityFinding, 0)
-				}
-				result[v] = append(result[v], f)
-			} else {
-				if _, ok := result[noTagSheetName]; !ok {
-					result[noTagSheetName] = make([]types.AwsSecurityFinding, 0)
-				}
-				result[noTagSheetName] = append(result[noTagSheetName], f)
-			}
-		}
+// applyOwnershipRules matches the ownership rules with the resources without the group key,
+// and returns the projects of the matched resources to override the group names
+func applyOwnershipRules(config *cfg.Config, findings []types.AwsSecurityFinding, options group.Options) (map[string]string, error) {
+	ownershipRules, err := ownership.LoadRules(config.OwnershipRulesFile)
+	if err != nil {
+		return nil, err
 	}
-	return result
-}
-
-func isArn(resourceId string) bool {
-	return strings.HasPrefix(resourceId, "arn:aws:")
-}
-
-func fixArn(resourceId string) string {
-	split := strings.Split(resourceId, ":")
-	lastPart := split[len(split)-1]
+	grouper, err := group.NewGrouper(config.GroupExpression(), options)
+	if err != nil {
+		return nil, err
+	}
+	assignments := ownershipRules.Apply(findings, *grouper)
 
-	if !strings.HasPrefix(lastPart, "i-") {
-		return resourceId
+	rule2Count := make(map[string]int, 0)
+	for _, a := range assignments {
+		rule2Count[a.Rule]++
+	}
+	log.Printf("%d resources without the group key\n", len(assignments))
+	rules := maps.Keys(rule2Count)
+	sort.Strings(rules)
+	for _, rule := range rules {
+		if rule == "" {
+			continue
+		}
+		log.Printf("  %d resources matched %s\n", rule2Count[rule], rule)
 	}
+	log.Printf("  %d resources matched no rule\n", rule2Count[""])
 
-	split[len(split)-1] = "instance/" + lastPart
-	fixedResourceId := strings.Join(split, ":")
-	return fixedResourceId
+	if config.OwnershipReportFile != "" {
+		log.Printf("Writing ownership report to '%s'...\n", config.OwnershipReportFile)
+		err = ownership.WriteReport(config.OwnershipReportFile, assignments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ownership.Overrides(assignments), nil
 }
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/sheet"
+	"golang.org/x/exp/slices"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var dryRun bool
+
+func init() {
+	c := &cobra.Command{
+		Use:     "import [options]",
+		Aliases: []string{"sync-back"},
+		Short:   "Import workflow status changes in Google Sheet to AWS SecurityHub.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport()
+		},
+	}
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "show the changes without updating the findings")
+
+	rootCmd.AddCommand(c)
+}
+
+func runImport() error {
+	loadConfig()
+	ctx := context.Background()
+
+	// Only the headers of the columns are needed to read the rows
+	columns, err := config.ReportColumns(nil)
+	if err != nil {
+		return err
+	}
+	log.Println("Initializing spreadsheet...")
+	client, err := sheet.NewSpreadSheet(config, columns)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Reading sheets...")
+	rows, err := client.GetFindingRows(config.ImportNoteColumn)
+	if err != nil {
+		return err
+	}
+	log.Printf("Got %d rows\n", len(rows))
+
+	ids := mapset.NewSet[string]()
+	for _, r := range rows {
+		ids.Add(r.Id)
+	}
+
+	log.Println("Fetching findings...")
+	findings, err := aws.GetFindingsByIds(ctx, ids.ToSlice())
+	if err != nil {
+		return err
+	}
+	log.Printf("Got %d findings\n", len(findings))
+
+	updates := createFindingUpdates(rows, findings)
+	if len(updates) == 0 {
+		log.Println("No changes found")
+		return nil
+	}
+
+	log.Printf("Found %d changes:\n", len(updates))
+	for _, u := range updates {
+		log.Printf("  %s\n", u.description)
+	}
+
+	if dryRun {
+		log.Println("Dry run, skipped updating findings")
+		return nil
+	}
+
+	log.Println("Updating findings...")
+	findingUpdates := make([]aws.FindingUpdate, 0)
+	for _, u := range updates {
+		findingUpdates = append(findingUpdates, u.FindingUpdate)
+	}
+	processed, err := aws.UpdateFindings(ctx, findingUpdates)
+	log.Printf("Updated %d findings\n", processed)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Finished!")
+	return nil
+}
+
+type findingUpdate struct {
+	aws.FindingUpdate
+	description string
+}
+
+// createFindingUpdates returns the updates of the findings edited in the sheets.
+// The workflow status is edited if it differs from that at the export, so that the changes in Security Hub
+// after the export are not reverted. Rows without the exported workflow status such as the resolved rows are skipped.
+func createFindingUpdates(rows []sheet.FindingRow, findings []types.AwsSecurityFinding) []findingUpdate {
+	id2Finding := make(map[string]types.AwsSecurityFinding, 0)
+	for _, f := range findings {
+		id2Finding[*f.Id] = f
+	}
+
+	ret := make([]findingUpdate, 0)
+	updatedIds := make(map[string]bool, 0)
+	for _, r := range rows {
+		f, ok := id2Finding[r.Id]
+		if !ok {
+			log.Printf("finding '%s' in sheet '%s' not found, skip to update.\n", r.Id, r.Sheet)
+			continue
+		}
+		if updatedIds[r.Id] {
+			continue
+		}
+		exported := parseWorkflowStatus(r.ExportedWorkflowStatus)
+		if exported == "" {
+			continue
+		}
+
+		status := parseWorkflowStatus(r.WorkflowStatus)
+		if !slices.Contains(status.Values(), status) {
+			log.Printf("invalid workflow status '%s' of finding '%s' in sheet '%s', skip to update.\n", r.WorkflowStatus, r.Id, r.Sheet)
+			continue
+		}
+
+		u := findingUpdate{
+			FindingUpdate: aws.FindingUpdate{
+				Id:         *f.Id,
+				ProductArn: *f.ProductArn,
+			},
+		}
+		changes := make([]string, 0)
+		current := types.WorkflowStatus("")
+		if f.Workflow != nil {
+			current = f.Workflow.Status
+		}
+		switch {
+		case status == exported || status == current:
+		case current != exported:
+			log.Printf("workflow status of finding '%s' in sheet '%s' has been changed to '%s' after the export, skip to update it.\n", r.Id, r.Sheet, current)
+		default:
+			u.WorkflowStatus = status
+			changes = append(changes, fmt.Sprintf("workflow status '%s' -> '%s'", current, status))
+		}
+		// Notes cannot be removed by BatchUpdateFindings, so an empty note is not a change
+		note := strings.TrimSpace(r.Note)
+		if note != "" && (f.Note == nil || awssdk.ToString(f.Note.Text) != note) {
+			u.Note = note
+			changes = append(changes, fmt.Sprintf("note '%s'", note))
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		u.description = fmt.Sprintf("[%s] %s: %s", r.Sheet, r.Id, strings.Join(changes, ", "))
+		ret = append(ret, u)
+		updatedIds[r.Id] = true
+	}
+	return ret
+}
+
+func parseWorkflowStatus(s string) types.WorkflowStatus {
+	return types.WorkflowStatus(strings.ToUpper(strings.TrimSpace(s)))
+}
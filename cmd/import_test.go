@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/sheet"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCreateFindingUpdates(t *testing.T) {
+	id := "id-1"
+	productArn := "arn:aws:securityhub:ap-northeast-1::product/aws/securityhub"
+	note := "checked"
+	finding := func(status types.WorkflowStatus, note *string) types.AwsSecurityFinding {
+		f := types.AwsSecurityFinding{Id: &id, ProductArn: &productArn, Workflow: &types.Workflow{Status: status}}
+		if note != nil {
+			f.Note = &types.Note{Text: note}
+		}
+		return f
+	}
+
+	tests := []struct {
+		name     string
+		row      sheet.FindingRow
+		finding  types.AwsSecurityFinding
+		expected []aws.FindingUpdate
+	}{
+		{
+			name:    "unchanged",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "NEW", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusNew, nil),
+		},
+		{
+			name:     "edited",
+			row:      sheet.FindingRow{Id: id, WorkflowStatus: " notified ", ExportedWorkflowStatus: "NEW"},
+			finding:  finding(types.WorkflowStatusNew, nil),
+			expected: []aws.FindingUpdate{{Id: id, ProductArn: productArn, WorkflowStatus: types.WorkflowStatusNotified}},
+		},
+		{
+			name:    "edited as in Security Hub",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "NOTIFIED", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusNotified, nil),
+		},
+		{
+			name:    "changed in Security Hub after the export",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "NEW", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusResolved, nil),
+		},
+		{
+			name:    "edited and changed in Security Hub after the export",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "SUPPRESSED", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusResolved, nil),
+		},
+		{
+			name:    "resolved",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "SUPPRESSED", Note: note},
+			finding: finding(types.WorkflowStatusNew, nil),
+		},
+		{
+			name:    "invalid status",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "DONE", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusNew, nil),
+		},
+		{
+			name:     "note",
+			row:      sheet.FindingRow{Id: id, WorkflowStatus: "NEW", ExportedWorkflowStatus: "NEW", Note: note},
+			finding:  finding(types.WorkflowStatusNew, nil),
+			expected: []aws.FindingUpdate{{Id: id, ProductArn: productArn, Note: note}},
+		},
+		{
+			name:    "same note",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "NEW", ExportedWorkflowStatus: "NEW", Note: note},
+			finding: finding(types.WorkflowStatusNew, &note),
+		},
+		{
+			name:     "note without text",
+			row:      sheet.FindingRow{Id: id, WorkflowStatus: "NEW", ExportedWorkflowStatus: "NEW", Note: note},
+			finding:  types.AwsSecurityFinding{Id: &id, ProductArn: &productArn, Workflow: &types.Workflow{Status: types.WorkflowStatusNew}, Note: &types.Note{}},
+			expected: []aws.FindingUpdate{{Id: id, ProductArn: productArn, Note: note}},
+		},
+		{
+			name:    "missing note",
+			row:     sheet.FindingRow{Id: id, WorkflowStatus: "NEW", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusNew, &note),
+		},
+		{
+			name:    "missing finding",
+			row:     sheet.FindingRow{Id: "id-2", WorkflowStatus: "NOTIFIED", ExportedWorkflowStatus: "NEW"},
+			finding: finding(types.WorkflowStatusNew, nil),
+		},
+	}
+	for _, tt := range tests {
+		updates := make([]aws.FindingUpdate, 0)
+		for _, u := range createFindingUpdates([]sheet.FindingRow{tt.row}, []types.AwsSecurityFinding{tt.finding}) {
+			updates = append(updates, u.FindingUpdate)
+		}
+		if tt.expected == nil {
+			assert.Empty(t, updates, tt.name)
+		} else {
+			assert.Equal(t, tt.expected, updates, tt.name)
+		}
+	}
+}
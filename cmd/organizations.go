@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cache"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"log"
+)
+
+const organizationsCacheFile = "organizations.json"
+
+// getOrganizationAccounts returns the accounts in AWS Organizations, from the cache if it has not expired
+func getOrganizationAccounts(ctx context.Context, config *cfg.Config) (map[string]aws.OrganizationAccount, error) {
+	if config.OrganizationsCacheTtl <= 0 {
+		log.Println("Fetching accounts from AWS Organizations...")
+		return aws.GetOrganizationAccounts(ctx)
+	}
+
+	path, err := config.CachePath(organizationsCacheFile)
+	if err != nil {
+		return nil, err
+	}
+	c := cache.NewFile[map[string]aws.OrganizationAccount](path, config.OrganizationsCacheTtl)
+
+	accounts, ok, err := c.Load()
+	if err != nil {
+		log.Printf("failed to load the cache of the accounts, fetching them again: %v\n", err)
+	}
+	if ok {
+		log.Printf("Got %d accounts from the cache '%s'\n", len(accounts), path)
+		return accounts, nil
+	}
+
+	log.Println("Fetching accounts from AWS Organizations...")
+	accounts, err = aws.GetOrganizationAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Got %d accounts\n", len(accounts))
+
+	err = c.Save(accounts)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/flatfile"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/kota65535/securityhub-exporter/sheet"
+	"github.com/kota65535/securityhub-exporter/xlsx"
+	"log"
+)
+
+// newExporters creates the exporters of the output targets in the config.
+// Google Sheet is the only target if none is configured.
+func newExporters(config cfg.Config, columns []report.Column) ([]exporter.Exporter, error) {
+	outputs := config.Outputs
+	if len(outputs) == 0 {
+		outputs = []cfg.Output{{Type: cfg.OutputTypeSheet}}
+	}
+
+	ret := make([]exporter.Exporter, 0)
+	for _, o := range outputs {
+		switch o.Type {
+		case cfg.OutputTypeSheet:
+			log.Println("Initializing spreadsheet...")
+			client, err := sheet.NewSpreadSheet(config, columns)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, client)
+		case cfg.OutputTypeXlsx:
+			workbook, err := xlsx.NewWorkbook(config, columns, o.Path)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, workbook)
+		case cfg.OutputTypeCsv:
+			e, err := flatfile.NewCSVExporter(o.Path, o.Combined, columns)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, e)
+		case cfg.OutputTypeJsonl:
+			e, err := flatfile.NewJSONLExporter(o.Path, columns)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, e)
+		default:
+			return nil, fmt.Errorf("unknown output type '%s'", o.Type)
+		}
+	}
+	return ret, nil
+}
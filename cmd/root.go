@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"github.com/kota65535/securityhub-exporter/cfg"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"os"
 )
 
+var configFile string
+var config cfg.Config
+
 var rootCmd = &cobra.Command{
 	Use:   "securityhub-exporter",
 	Short: "Export AWS SecurityHub findings to Google Sheet.",
@@ -12,6 +17,21 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "config.yml", "config file path")
+}
+
+func loadConfig() {
+	viper.SetConfigFile(configFile)
+	viper.AutomaticEnv()
+
+	err := viper.ReadInConfig()
+	cobra.CheckErr(err)
+
+	err = viper.Unmarshal(&config)
+	cobra.CheckErr(err)
+
+	err = config.Validate()
+	cobra.CheckErr(err)
 }
 
 func Execute() {
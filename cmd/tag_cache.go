@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cache"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"log"
+)
+
+const tagCacheFile = "tags.json"
+
+// getResourcesTags returns the tags of the resources, from the cache for the resources fetched within the TTL.
+// The resources whose tags are not found are also cached with no tags, for the negative TTL.
+// The resources whose tags failed to be fetched are not cached, to fetch them again in the next run.
+func getResourcesTags(ctx context.Context, config *cfg.Config, resources []aws.Resource) (aws.ResourceID2Tags, error) {
+	if config.TagCacheTtl <= 0 {
+		ret, _, err := fetchResourcesTags(ctx, config, resources)
+		return ret, err
+	}
+
+	path, err := config.CachePath(tagCacheFile)
+	if err != nil {
+		return nil, err
+	}
+	store, err := cache.OpenStore[[]types.Tag](path)
+	if err != nil {
+		log.Printf("failed to load the tag cache, fetching all the tags again: %v\n", err)
+		store = cache.NewStore[[]types.Tag](path)
+	}
+
+	ret := make(aws.ResourceID2Tags, 0)
+	missed := make([]aws.Resource, 0)
+	for _, r := range resources {
+		if tags, ok := store.Get(r.Id); ok {
+			ret[r.Id] = tags
+			continue
+		}
+		missed = append(missed, r)
+	}
+	log.Printf("Got tags of %d resources from the cache, fetching %d resources...\n", len(ret), len(missed))
+	if len(missed) == 0 {
+		return ret, nil
+	}
+
+	fetched, failed, err := fetchResourcesTags(ctx, config, missed)
+	if err != nil {
+		return nil, err
+	}
+	putResourcesTags(config, store, missed, fetched, failed)
+	for id, tags := range fetched {
+		ret[id] = tags
+	}
+
+	err = store.Save()
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// putResourcesTags puts the fetched tags of the resources to the store.
+// The resources not found are put with no tags for the negative TTL, except the failed ones.
+func putResourcesTags(config *cfg.Config, store *cache.Store[[]types.Tag], resources []aws.Resource, fetched aws.ResourceID2Tags, failed []string) {
+	negativeTtl := config.TagCacheNegativeTtl
+	if negativeTtl <= 0 {
+		negativeTtl = config.TagCacheTtl
+	}
+	failedIds := make(map[string]bool, 0)
+	for _, id := range failed {
+		failedIds[id] = true
+	}
+	for _, r := range resources {
+		if tags, ok := fetched[r.Id]; ok {
+			store.Put(r.Id, tags, config.TagCacheTtl)
+			continue
+		}
+		if failedIds[r.Id] {
+			continue
+		}
+		store.Put(r.Id, []types.Tag{}, negativeTtl)
+	}
+}
+
+// fetchResourcesTags returns the tags of the resources by the Resource Groups Tagging API,
+// and by the API of each service for the resources which the tagging API does not return.
+// The IDs of the resources whose tags failed to be fetched by the service APIs are also returned.
+func fetchResourcesTags(ctx context.Context, config *cfg.Config, resources []aws.Resource) (aws.ResourceID2Tags, []string, error) {
+	arns := make([]string, 0)
+	regionHints := make(map[string]string, 0)
+	for _, r := range resources {
+		if !aws.IsArn(r.Id) {
+			continue
+		}
+		arns = append(arns, r.Id)
+		if r.Region != "" {
+			regionHints[r.Id] = r.Region
+		}
+	}
+	ret, err := aws.GetResourcesTags(ctx, arns, config.Accounts, regionHints)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rest := make([]aws.Resource, 0)
+	for _, r := range resources {
+		if _, ok := ret[r.Id]; !ok {
+			rest = append(rest, r)
+		}
+	}
+	if len(rest) == 0 {
+		return ret, nil, nil
+	}
+	log.Printf("Fetching tags of %d resources not found by the tagging API...\n", len(rest))
+	fetched, failed, err := aws.GetResourcesTagsByService(ctx, rest, config.Accounts)
+	if err != nil {
+		return nil, nil, err
+	}
+	for id, tags := range fetched {
+		ret[id] = tags
+	}
+	return ret, failed, nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cache"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPutResourcesTags(t *testing.T) {
+	store := cache.NewStore[[]types.Tag](filepath.Join(t.TempDir(), tagCacheFile))
+	config := &cfg.Config{TagCacheTtl: time.Hour}
+	key, value := "Project", "foo"
+	tags := []types.Tag{{Key: &key, Value: &value}}
+	resources := []aws.Resource{{Id: "found"}, {Id: "not-found"}, {Id: "failed"}}
+
+	putResourcesTags(config, store, resources, aws.ResourceID2Tags{"found": tags}, []string{"failed"})
+
+	cached, ok := store.Get("found")
+	assert.True(t, ok)
+	assert.Equal(t, tags, cached)
+	cached, ok = store.Get("not-found")
+	assert.True(t, ok)
+	assert.Empty(t, cached)
+	// Fetched again in the next run instead of hiding the tags for the TTL
+	_, ok = store.Get("failed")
+	assert.False(t, ok)
+}
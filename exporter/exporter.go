@@ -0,0 +1,36 @@
+package exporter
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"time"
+)
+
+// Project2Findings is the findings grouped by project
+type Project2Findings map[string][]types.AwsSecurityFinding
+
+// Metadata is the information about the export run
+type Metadata struct {
+	// Time when the findings were fetched, zero if unknown like the findings loaded from an ASFF file
+	FetchedAt time.Time
+	// Version of the exporter
+	Version string
+	// Custom insights, whose findings are also included as projects named by InsightProjectName
+	Insights []aws.Insight
+	// Findings of all the compliance statuses by project for the control sheets, including the passed findings
+	// which are excluded from the projects
+	ControlProject2Findings Project2Findings
+}
+
+// InsightProjectName returns the name of the project holding the findings of the insight
+func InsightProjectName(insightName string) string {
+	return "Insight: " + insightName
+}
+
+// Exporter exports the grouped findings to an output target
+type Exporter interface {
+	// Name returns the name of the output target for logging
+	Name() string
+	Export(ctx context.Context, project2Findings Project2Findings, metadata Metadata) error
+}
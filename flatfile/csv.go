@@ -0,0 +1,97 @@
+package flatfile
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CSVExporter exports the findings to the CSV files.
+// If Combined is true, the findings of all the projects are written to the file at Path with the project column,
+// otherwise the findings of each project are written to its own file in the directory at Path.
+type CSVExporter struct {
+	Path     string
+	Combined bool
+	// Columns of the rows of findings
+	Columns []report.Column
+}
+
+func NewCSVExporter(path string, combined bool, columns []report.Column) (*CSVExporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path of the csv output is required")
+	}
+	err := CheckColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVExporter{Path: path, Combined: combined, Columns: columns}, nil
+}
+
+func (e CSVExporter) Name() string {
+	return fmt.Sprintf("CSV '%s'", e.Path)
+}
+
+func (e CSVExporter) Export(_ context.Context, project2Findings exporter.Project2Findings, _ exporter.Metadata) error {
+	if e.Combined {
+		rows := make([][]interface{}, 0)
+		for _, project := range report.SortedProjects(project2Findings) {
+			rows = append(rows, records(e.Columns, project2Findings, project, true)...)
+		}
+		return writeCSV(e.Path, header(e.Columns, true), rows)
+	}
+
+	names := make(map[string]bool, 0)
+	for _, project := range report.SortedProjects(project2Findings) {
+		name := uniqueFileName(project, names)
+		names[strings.ToLower(name)] = true
+		path := filepath.Join(e.Path, name+".csv")
+		log.Printf("Writing CSV for '%s'...", project)
+		err := writeCSV(path, header(e.Columns, false), records(e.Columns, project2Findings, project, false))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(path string, header []string, rows [][]interface{}) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	err = w.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, 0)
+		for _, v := range row {
+			if v == nil {
+				v = ""
+			}
+			record = append(record, fmt.Sprint(v))
+		}
+		err = w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
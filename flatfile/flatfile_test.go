@@ -0,0 +1,124 @@
+package flatfile
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFinding(id string) types.AwsSecurityFinding {
+	region := "ap-northeast-1"
+	accountId := "111111111111"
+	title := "title of " + id
+	resourceId := "arn:aws:s3:::bucket"
+	timestamp := "2023-08-01T12:34:56.000Z"
+	productName := "Security Hub"
+	return types.AwsSecurityFinding{
+		Id:           &id,
+		ProductName:  &productName,
+		Title:        &title,
+		Region:       &region,
+		AwsAccountId: &accountId,
+		Severity:     &types.Severity{Label: types.SeverityLabelHigh, Normalized: 70},
+		Workflow:     &types.Workflow{Status: types.WorkflowStatusNew},
+		Resources:    []types.Resource{{Id: &resourceId}},
+		CreatedAt:    &timestamp,
+		UpdatedAt:    &timestamp,
+	}
+}
+
+var project2Findings = exporter.Project2Findings{
+	"foo":      {newFinding("a")},
+	"(No Tag)": {newFinding("b")},
+}
+
+func TestCSVExporter(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewCSVExporter(dir, false, report.DefaultColumns())
+	assert.NoError(t, err)
+	err = e.Export(context.Background(), project2Findings, exporter.Metadata{})
+	assert.NoError(t, err)
+
+	file, err := os.Open(filepath.Join(dir, "foo.csv"))
+	assert.NoError(t, err)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "ID", rows[0][0])
+	assert.Equal(t, "URL", rows[0][len(rows[0])-1])
+	assert.Equal(t, "Security Hub", rows[1][5])
+	assert.Equal(t, "2023-08-01T12:34:56.000Z", rows[1][8])
+	assert.FileExists(t, filepath.Join(dir, "(No Tag).csv"))
+}
+
+func TestCSVExporterCombined(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.csv")
+	e, err := NewCSVExporter(path, true, report.DefaultColumns())
+	assert.NoError(t, err)
+	err = e.Export(context.Background(), project2Findings, exporter.Metadata{})
+	assert.NoError(t, err)
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"Project", "ID"}, rows[0][:2])
+	assert.Equal(t, []string{"(No Tag)", "b"}, rows[1][:2])
+	assert.Equal(t, []string{"foo", "a"}, rows[2][:2])
+}
+
+func TestJSONLExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.jsonl")
+	e, err := NewJSONLExporter(path, report.DefaultColumns())
+	assert.NoError(t, err)
+	err = e.Export(context.Background(), project2Findings, exporter.Metadata{})
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], `{"project":"(No Tag)","id":"b","severity":"HIGH",`))
+
+	var record map[string]interface{}
+	err = json.Unmarshal([]byte(lines[1]), &record)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", record["project"])
+	assert.Equal(t, "2023-08-01T12:34:56.000Z", record["updated_at"])
+	assert.Contains(t, record["url"], "console.aws.amazon.com")
+}
+
+func TestCSVExporterFileNames(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewCSVExporter(dir, false, report.DefaultColumns())
+	assert.NoError(t, err)
+	err = e.Export(context.Background(), exporter.Project2Findings{
+		"a/b": {newFinding("a")},
+		"a_b": {newFinding("b")},
+	}, exporter.Metadata{})
+	assert.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(dir, "a_b.csv"))
+	assert.FileExists(t, filepath.Join(dir, "a_b (2).csv"))
+}
+
+func TestCheckColumns(t *testing.T) {
+	assert.NoError(t, CheckColumns(report.DefaultColumns()))
+	for _, name := range []string{"Project", "url", "Workflow-Status"} {
+		assert.Error(t, CheckColumns(append(report.DefaultColumns(), report.Column{Name: name})), name)
+	}
+
+	_, err := NewJSONLExporter("findings.jsonl", append(report.DefaultColumns(), report.Column{Name: "Project"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package flatfile
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
+	"os"
+	"path/filepath"
+)
+
+// JSONLExporter exports the findings of all the projects to the JSON Lines file.
+// Each line is a JSON object with the keys in the same order as the columns.
+type JSONLExporter struct {
+	Path string
+	// Columns of the rows of findings
+	Columns []report.Column
+}
+
+func NewJSONLExporter(path string, columns []report.Column) (*JSONLExporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path of the jsonl output is required")
+	}
+	err := CheckColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLExporter{Path: path, Columns: columns}, nil
+}
+
+func (e JSONLExporter) Name() string {
+	return fmt.Sprintf("JSON Lines '%s'", e.Path)
+}
+
+func (e JSONLExporter) Export(_ context.Context, project2Findings exporter.Project2Findings, _ exporter.Metadata) error {
+	err := os.MkdirAll(filepath.Dir(e.Path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(e.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	keys := header(e.Columns, true)
+	for _, project := range report.SortedProjects(project2Findings) {
+		for _, row := range records(e.Columns, project2Findings, project, true) {
+			line, err := marshalOrdered(keys, row)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(line, '\n'))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// marshalOrdered marshals the values to a JSON object keeping the order of the keys, unlike maps
+func marshalOrdered(keys []string, values []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(fieldKey(k))
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package flatfile
+
+import (
+	"fmt"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
+	"regexp"
+	"strings"
+)
+
+const (
+	projectColumnName = "Project"
+	urlColumnName     = "URL"
+)
+
+// Characters not allowed in the file names
+var invalidFileNameChars = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_",
+)
+
+// uniqueFileName returns the file name for the project without the extension, which differs from the existing names
+// even after the invalid characters are replaced. The existing names must be in lower case since some file systems
+// are case-insensitive.
+func uniqueFileName(project string, existing map[string]bool) string {
+	name := invalidFileNameChars.Replace(project)
+	ret := name
+	for i := 2; existing[strings.ToLower(ret)]; i++ {
+		ret = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return ret
+}
+
+// CheckColumns checks that the keys of the columns in the JSON Lines output, including those of the project and the URL,
+// do not collide with each other, which also rejects the CSV header with the duplicate project or URL column
+func CheckColumns(columns []report.Column) error {
+	key2Name := make(map[string]string, 0)
+	for _, name := range header(columns, true) {
+		key := fieldKey(name)
+		if other, ok := key2Name[key]; ok {
+			return fmt.Errorf("column '%s' collides with column '%s' in the csv or jsonl outputs, whose key is '%s'", name, other, key)
+		}
+		key2Name[key] = name
+	}
+	return nil
+}
+
+// header returns the names of the columns, optionally preceded by the project column
+func header(columns []report.Column, withProject bool) []string {
+	ret := make([]string, 0)
+	if withProject {
+		ret = append(ret, projectColumnName)
+	}
+	for _, c := range columns {
+		ret = append(ret, c.Name)
+	}
+	return append(ret, urlColumnName)
+}
+
+// records returns the rows of the findings of the project followed by the console URL,
+// optionally preceded by the project
+func records(columns []report.Column, project2Findings exporter.Project2Findings, project string, withProject bool) [][]interface{} {
+	findings := project2Findings[project]
+	report.SortFindings(findings)
+
+	ret := make([][]interface{}, 0)
+	for i, values := range report.CreateRecordValues(columns, findings) {
+		row := make([]interface{}, 0)
+		if withProject {
+			row = append(row, project)
+		}
+		row = append(row, values...)
+		row = append(row, report.CreateUriToFinding(*findings[i].Id, *findings[i].Region))
+		ret = append(ret, row)
+	}
+	return ret
+}
+
+// Characters replaced with '_' in the keys of the JSON fields
+var nonWordChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// fieldKey returns the key of the column in the machine-readable outputs, such as 'workflow_status'
+func fieldKey(name string) string {
+	return strings.Trim(nonWordChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
@@ -0,0 +1,141 @@
+package group
+
+import (
+	"fmt"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"regexp"
+	"strings"
+)
+
+// NoTagName is the group name for the findings whose resources do not have the tag
+const NoTagName = "(No Tag)"
+
+// Policies of the findings with multiple resources
+const (
+	// MultiResourcePolicyFinding puts a finding once in each group of its resources, with all the resources
+	MultiResourcePolicyFinding = "finding"
+	// MultiResourcePolicyResource puts a copy of a finding for each resource, with only the resource
+	MultiResourcePolicyResource = "resource"
+)
+
+var termPattern = regexp.MustCompile(`\$\{([^}]*)}`)
+
+// Grouper groups the findings by the expression such as '${account} - ${tag:Project}'.
+// An expression without '${...}' is a single attribute such as 'account' or 'tag:Project'.
+type Grouper struct {
+	// Literal strings and strategies in the expression, either of which is set in each part
+	parts []part
+	// Policy of the findings with multiple resources
+	multiResourcePolicy string
+	// Group names by the OverrideKey of the finding and the resource
+	overrides map[string]string
+}
+
+type part struct {
+	literal  string
+	strategy Strategy
+}
+
+func NewGrouper(expression string, options Options) (*Grouper, error) {
+	if !strings.Contains(expression, "${") {
+		expression = "${" + expression + "}"
+	}
+
+	ret := &Grouper{multiResourcePolicy: options.MultiResourcePolicy, overrides: options.Overrides}
+	switch ret.multiResourcePolicy {
+	case "":
+		ret.multiResourcePolicy = MultiResourcePolicyFinding
+	case MultiResourcePolicyFinding, MultiResourcePolicyResource:
+	default:
+		return nil, fmt.Errorf("invalid multi resource policy '%s'", options.MultiResourcePolicy)
+	}
+
+	last := 0
+	for _, m := range termPattern.FindAllStringSubmatchIndex(expression, -1) {
+		if m[0] > last {
+			ret.parts = append(ret.parts, part{literal: expression[last:m[0]]})
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(expression[m[2]:m[3]]), ":")
+		s, err := newStrategy(name, arg, options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group expression '%s': %w", expression, err)
+		}
+		ret.parts = append(ret.parts, part{strategy: s})
+		last = m[1]
+	}
+	if last < len(expression) {
+		ret.parts = append(ret.parts, part{literal: expression[last:]})
+	}
+	return ret, nil
+}
+
+// OverrideKey returns the key of the finding and the resource in the overrides of the group names
+func OverrideKey(findingId string, resourceId string) string {
+	return findingId + "\n" + resourceId
+}
+
+// Key returns the group name of the finding for the resource
+func (g Grouper) Key(f types.AwsSecurityFinding, r types.Resource) string {
+	if k, ok := g.overrides[OverrideKey(awssdk.ToString(f.Id), awssdk.ToString(r.Id))]; ok {
+		return k
+	}
+	var b strings.Builder
+	for _, p := range g.parts {
+		if p.strategy == nil {
+			b.WriteString(p.literal)
+			continue
+		}
+		v, ok := p.strategy.Value(f, r)
+		if !ok {
+			v = p.strategy.MissingName()
+		}
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+// HasKey returns whether the finding has all the attributes of the expression for the resource,
+// ignoring the overrides
+func (g Grouper) HasKey(f types.AwsSecurityFinding, r types.Resource) bool {
+	for _, p := range g.parts {
+		if p.strategy == nil {
+			continue
+		}
+		if _, ok := p.strategy.Value(f, r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Group groups the findings by the key of each resource.
+// A finding with multiple resources is put once in each group, or copied for each resource by the policy.
+func (g Grouper) Group(findings []types.AwsSecurityFinding) exporter.Project2Findings {
+	for _, p := range g.parts {
+		if s, ok := p.strategy.(tagStrategy); ok {
+			s.countSpellings(findings)
+		}
+	}
+
+	result := make(exporter.Project2Findings, 0)
+	for _, f := range findings {
+		keys := make(map[string]bool, 0)
+		for _, r := range f.Resources {
+			k := g.Key(f, r)
+			if g.multiResourcePolicy == MultiResourcePolicyResource {
+				c := f
+				c.Resources = []types.Resource{r}
+				result[k] = append(result[k], c)
+				continue
+			}
+			if keys[k] {
+				continue
+			}
+			keys[k] = true
+			result[k] = append(result[k], f)
+		}
+	}
+	return result
+}
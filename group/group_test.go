@@ -0,0 +1,174 @@
+package group
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
+	"testing"
+)
+
+func newFinding(id string, accountId string, resources ...types.Resource) types.AwsSecurityFinding {
+	region := "ap-northeast-1"
+	controlId := "S3.1"
+	return types.AwsSecurityFinding{
+		Id:           &id,
+		AwsAccountId: &accountId,
+		Region:       &region,
+		Compliance:   &types.Compliance{SecurityControlId: &controlId},
+		Resources:    resources,
+	}
+}
+
+func newResource(resourceType string, tags map[string]string) types.Resource {
+	id := "arn:aws:s3:::bucket"
+	return types.Resource{Id: &id, Type: &resourceType, Tags: tags}
+}
+
+func TestGroupByTag(t *testing.T) {
+	g, err := NewGrouper("tag:Project", Options{})
+	assert.NoError(t, err)
+
+	result := g.Group([]types.AwsSecurityFinding{
+		newFinding("a", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "foo"})),
+		newFinding("b", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "bar"})),
+		newFinding("c", "111111111111", newResource("AwsS3Bucket", nil)),
+	})
+	assert.Len(t, result, 3)
+	assert.Equal(t, "a", *result["foo"][0].Id)
+	assert.Equal(t, "b", *result["bar"][0].Id)
+	assert.Equal(t, "c", *result[NoTagName][0].Id)
+}
+
+func TestGroupByTagFallback(t *testing.T) {
+	g, err := NewGrouper("tag:Project|Service", Options{
+		IgnoreTagKeyCase: true,
+		TagValueAliases:  map[string]string{"billing-team": "Billing"},
+	})
+	assert.NoError(t, err)
+
+	result := g.Group([]types.AwsSecurityFinding{
+		newFinding("a", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "Billing"})),
+		newFinding("b", "111111111111", newResource("AwsS3Bucket", map[string]string{"project": "billing"})),
+		newFinding("c", "111111111111", newResource("AwsS3Bucket", map[string]string{"Service": "billing-team"})),
+		newFinding("d", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "Payment", "Service": "Billing"})),
+		newFinding("e", "111111111111", newResource("AwsS3Bucket", map[string]string{"Owner": "Billing"})),
+	})
+	assert.Len(t, result, 3)
+	assert.Len(t, result["Billing"], 3)
+	assert.Equal(t, "d", *result["Payment"][0].Id)
+	assert.Equal(t, "e", *result[NoTagName][0].Id)
+}
+
+func TestGroupByTagValueCase(t *testing.T) {
+	g, err := NewGrouper("tag:Project", Options{})
+	assert.NoError(t, err)
+
+	// The most frequent spelling is the name regardless of the order of the findings
+	findings := []types.AwsSecurityFinding{
+		newFinding("a", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "billing"})),
+		newFinding("b", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "Billing"})),
+		newFinding("c", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "BILLING"})),
+		newFinding("d", "111111111111", newResource("AwsS3Bucket", map[string]string{"Project": "Billing"})),
+	}
+	result := g.Group(findings)
+	assert.Len(t, result, 1)
+	assert.Len(t, result["Billing"], 4)
+	slices.Reverse(findings)
+	assert.Len(t, g.Group(findings)["Billing"], 4)
+
+	// The lexicographically first spelling in a tie
+	result = g.Group(findings[1:])
+	assert.Len(t, result, 1)
+	assert.Len(t, result["BILLING"], 3)
+}
+
+func TestGroupByTagCaseSensitive(t *testing.T) {
+	g, err := NewGrouper("tag:Project", Options{})
+	assert.NoError(t, err)
+
+	result := g.Group([]types.AwsSecurityFinding{
+		newFinding("a", "111111111111", newResource("AwsS3Bucket", map[string]string{"project": "billing"})),
+	})
+	assert.Equal(t, "a", *result[NoTagName][0].Id)
+}
+
+func TestGroupByExpression(t *testing.T) {
+	g, err := NewGrouper("${account} / ${resourceType} (${control})", Options{
+		AccountNames: map[string]string{"111111111111": "production"},
+	})
+	assert.NoError(t, err)
+
+	result := g.Group([]types.AwsSecurityFinding{
+		newFinding("a", "111111111111", newResource("AwsS3Bucket", nil)),
+		newFinding("b", "222222222222", newResource("AwsS3Bucket", nil)),
+		newFinding("c", "222222222222", newResource("", nil)),
+	})
+	assert.Len(t, result, 3)
+	assert.Equal(t, "a", *result["production / AwsS3Bucket (S3.1)"][0].Id)
+	assert.Equal(t, "b", *result["222222222222 / AwsS3Bucket (S3.1)"][0].Id)
+	assert.Equal(t, "c", *result["222222222222 / (No Resource Type) (S3.1)"][0].Id)
+}
+
+func TestGroupByOrganization(t *testing.T) {
+	accounts := map[string]aws.OrganizationAccount{
+		"111111111111": {Id: "111111111111", Name: "production", OuPath: "Root/Workloads", Tags: map[string]string{"CostCenter": "1234"}},
+	}
+	findings := []types.AwsSecurityFinding{
+		newFinding("a", "111111111111", newResource("AwsS3Bucket", nil)),
+		newFinding("b", "222222222222", newResource("AwsS3Bucket", nil)),
+	}
+
+	g, err := NewGrouper("${account} (${ou}) ${accountTag:CostCenter}", Options{Accounts: accounts})
+	assert.NoError(t, err)
+	result := g.Group(findings)
+	assert.Equal(t, "a", *result["production (Root/Workloads) 1234"][0].Id)
+	assert.Equal(t, "b", *result["222222222222 ((No OU)) (No Account Tag)"][0].Id)
+	assert.Nil(t, findings[0].UserDefinedFields)
+
+	// Names in the config take precedence
+	g, err = NewGrouper("account", Options{AccountNames: map[string]string{"111111111111": "prod"}, Accounts: accounts})
+	assert.NoError(t, err)
+	result = g.Group(findings)
+	assert.Equal(t, "a", *result["prod"][0].Id)
+}
+
+func TestGroupMultiResource(t *testing.T) {
+	withId := func(r types.Resource, id string) types.Resource {
+		r.Id = &id
+		return r
+	}
+	findings := []types.AwsSecurityFinding{
+		newFinding("a", "111111111111",
+			withId(newResource("AwsS3Bucket", map[string]string{"Project": "foo"}), "bucket-1"),
+			withId(newResource("AwsS3Bucket", map[string]string{"Project": "foo"}), "bucket-2"),
+			withId(newResource("AwsS3Bucket", map[string]string{"Project": "bar"}), "bucket-3"),
+		),
+	}
+
+	g, err := NewGrouper("tag:Project", Options{})
+	assert.NoError(t, err)
+	result := g.Group(findings)
+	assert.Len(t, result["foo"], 1)
+	assert.Len(t, result["foo"][0].Resources, 3)
+	assert.Len(t, result["bar"], 1)
+
+	g, err = NewGrouper("tag:Project", Options{MultiResourcePolicy: MultiResourcePolicyResource})
+	assert.NoError(t, err)
+	result = g.Group(findings)
+	assert.Len(t, result["foo"], 2)
+	assert.Equal(t, "bucket-1", *result["foo"][0].Resources[0].Id)
+	assert.Equal(t, "bucket-2", *result["foo"][1].Resources[0].Id)
+	assert.Len(t, result["bar"], 1)
+	assert.Equal(t, "bucket-3", *result["bar"][0].Resources[0].Id)
+	assert.Len(t, findings[0].Resources, 3)
+}
+
+func TestNewGrouperInvalid(t *testing.T) {
+	for _, expression := range []string{"tag", "owner", "${account}-${foo}"} {
+		_, err := NewGrouper(expression, Options{})
+		assert.Error(t, err, expression)
+	}
+	_, err := NewGrouper("account", Options{MultiResourcePolicy: "foo"})
+	assert.Error(t, err)
+}
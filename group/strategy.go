@@ -0,0 +1,223 @@
+package group
+
+import (
+	"fmt"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"golang.org/x/exp/maps"
+	"sort"
+	"strings"
+)
+
+// Strategy returns the value of an attribute of the finding by which the findings are grouped.
+// It returns false if the finding or the resource does not have the attribute.
+type Strategy interface {
+	Value(f types.AwsSecurityFinding, r types.Resource) (string, bool)
+	// MissingName returns the group name for the findings without the attribute
+	MissingName() string
+}
+
+// Options are the settings shared by the strategies
+type Options struct {
+	// Friendly names by account ID
+	AccountNames map[string]string
+	// Accounts in the organization by account ID
+	Accounts map[string]aws.OrganizationAccount
+	// Whether the tag keys are matched case-insensitively
+	IgnoreTagKeyCase bool
+	// Canonical names by tag value, matched case-insensitively
+	TagValueAliases map[string]string
+	// Policy of the findings with multiple resources, "finding" if empty
+	MultiResourcePolicy string
+	// Group names by the OverrideKey of the finding and the resource, which take precedence over the expression
+	Overrides map[string]string
+}
+
+// newStrategy creates the strategy of the name, with the argument such as the tag key
+func newStrategy(name string, arg string, options Options) (Strategy, error) {
+	switch strings.ToLower(name) {
+	case "tag":
+		keys := make([]string, 0)
+		for _, k := range strings.Split(arg, "|") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("tag key is required like 'tag:Project' or 'tag:Project|Service'")
+		}
+		return newTagStrategy(keys, options), nil
+	case "account":
+		return accountStrategy{names: options.AccountNames, accounts: options.Accounts}, nil
+	case "accountid":
+		return attributeStrategy{name: "Account", value: func(f types.AwsSecurityFinding, _ types.Resource) string {
+			return awssdk.ToString(f.AwsAccountId)
+		}}, nil
+	case "region":
+		return attributeStrategy{name: "Region", value: func(f types.AwsSecurityFinding, r types.Resource) string {
+			if r.Region != nil {
+				return *r.Region
+			}
+			return awssdk.ToString(f.Region)
+		}}, nil
+	case "product":
+		return attributeStrategy{name: "Product", value: func(f types.AwsSecurityFinding, _ types.Resource) string {
+			return awssdk.ToString(f.ProductName)
+		}}, nil
+	case "resourcetype":
+		return attributeStrategy{name: "Resource Type", value: func(_ types.AwsSecurityFinding, r types.Resource) string {
+			return awssdk.ToString(r.Type)
+		}}, nil
+	case "control":
+		return attributeStrategy{name: "Control", value: func(f types.AwsSecurityFinding, _ types.Resource) string {
+			if f.Compliance == nil {
+				return ""
+			}
+			return awssdk.ToString(f.Compliance.SecurityControlId)
+		}}, nil
+	case "ou":
+		return attributeStrategy{name: "OU", value: func(f types.AwsSecurityFinding, _ types.Resource) string {
+			return options.Accounts[awssdk.ToString(f.AwsAccountId)].OuPath
+		}}, nil
+	case "accounttag":
+		if arg == "" {
+			return nil, fmt.Errorf("account tag key is required like 'accountTag:CostCenter'")
+		}
+		return attributeStrategy{name: "Account Tag", value: func(f types.AwsSecurityFinding, _ types.Resource) string {
+			return options.Accounts[awssdk.ToString(f.AwsAccountId)].Tags[arg]
+		}}, nil
+	}
+	return nil, fmt.Errorf("unknown grouping attribute '%s', available values are: "+
+		"tag:<key>, account, accountId, region, product, resourceType, control, ou, accountTag:<key>", name)
+}
+
+// tagStrategy groups by the value of the first resource tag found in the keys, normalized by the aliases
+type tagStrategy struct {
+	keys          []string
+	ignoreKeyCase bool
+	// Canonical names by lower-cased alias or canonical name itself
+	aliases map[string]string
+	// Number of the resources by spelling by lower-cased value of the findings being grouped
+	spellings map[string]map[string]int
+}
+
+func newTagStrategy(keys []string, options Options) tagStrategy {
+	aliases := make(map[string]string, 0)
+	for _, canonical := range options.TagValueAliases {
+		aliases[strings.ToLower(canonical)] = canonical
+	}
+	for alias, canonical := range options.TagValueAliases {
+		aliases[strings.ToLower(alias)] = canonical
+	}
+	return tagStrategy{keys: keys, ignoreKeyCase: options.IgnoreTagKeyCase, aliases: aliases, spellings: make(map[string]map[string]int, 0)}
+}
+
+func (s tagStrategy) Value(_ types.AwsSecurityFinding, r types.Resource) (string, bool) {
+	v, ok := LookupTag(r.Tags, s.keys, s.ignoreKeyCase)
+	if !ok {
+		return "", false
+	}
+	return s.normalize(v), true
+}
+
+// LookupTag returns the value of the first tag found in the keys
+func LookupTag(tags map[string]string, keys []string, ignoreKeyCase bool) (string, bool) {
+	for _, key := range keys {
+		if v, ok := tags[key]; ok {
+			return v, true
+		}
+		if !ignoreKeyCase {
+			continue
+		}
+		// Sort the keys so that the same key is chosen if some differ only in case
+		tagKeys := maps.Keys(tags)
+		sort.Strings(tagKeys)
+		for _, k := range tagKeys {
+			if strings.EqualFold(k, key) {
+				return tags[k], true
+			}
+		}
+	}
+	return "", false
+}
+
+// countSpellings counts the spellings of the tag values of the findings, replacing those counted before
+func (s tagStrategy) countSpellings(findings []types.AwsSecurityFinding) {
+	maps.Clear(s.spellings)
+	for _, f := range findings {
+		for _, r := range f.Resources {
+			v, ok := LookupTag(r.Tags, s.keys, s.ignoreKeyCase)
+			if !ok {
+				continue
+			}
+			lower := strings.ToLower(v)
+			if s.spellings[lower] == nil {
+				s.spellings[lower] = make(map[string]int, 0)
+			}
+			s.spellings[lower][v]++
+		}
+	}
+}
+
+// normalize returns the canonical name of the value. Without an alias, it is the most frequent spelling counted
+// and the lexicographically first one in a tie, so that it does not depend on the order of the findings.
+func (s tagStrategy) normalize(value string) string {
+	lower := strings.ToLower(value)
+	if canonical, ok := s.aliases[lower]; ok {
+		return canonical
+	}
+	ret := value
+	count := 0
+	for spelling, n := range s.spellings[lower] {
+		if n > count || (n == count && spelling < ret) {
+			ret = spelling
+			count = n
+		}
+	}
+	return ret
+}
+
+func (s tagStrategy) MissingName() string {
+	return NoTagName
+}
+
+// accountStrategy groups by the friendly name of the account in the config or in the organization,
+// or the account ID if the name is unknown
+type accountStrategy struct {
+	names    map[string]string
+	accounts map[string]aws.OrganizationAccount
+}
+
+func (s accountStrategy) Value(f types.AwsSecurityFinding, _ types.Resource) (string, bool) {
+	id := awssdk.ToString(f.AwsAccountId)
+	if id == "" {
+		return "", false
+	}
+	if name, ok := s.names[id]; ok {
+		return name, true
+	}
+	if name := s.accounts[id].Name; name != "" {
+		return name, true
+	}
+	return id, true
+}
+
+func (s accountStrategy) MissingName() string {
+	return "(No Account)"
+}
+
+// attributeStrategy groups by the attribute of the finding or the resource
+type attributeStrategy struct {
+	name  string
+	value func(f types.AwsSecurityFinding, r types.Resource) string
+}
+
+func (s attributeStrategy) Value(f types.AwsSecurityFinding, r types.Resource) (string, bool) {
+	v := s.value(f, r)
+	return v, v != ""
+}
+
+func (s attributeStrategy) MissingName() string {
+	return fmt.Sprintf("(No %s)", s.name)
+}
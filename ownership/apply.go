@@ -0,0 +1,80 @@
+package ownership
+
+import (
+	"encoding/csv"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/group"
+	"os"
+	"path/filepath"
+)
+
+// Assignment is the result of the rules for a resource without the group key
+type Assignment struct {
+	FindingId  string
+	ResourceId string
+	// Name of the matched rule, empty if no rule matched
+	Rule    string
+	Project string
+}
+
+// Apply matches the rules with the resources for which the grouper has no key.
+// It returns the assignments of all those resources, including those no rule matched.
+func (r Rules) Apply(findings []types.AwsSecurityFinding, grouper group.Grouper) []Assignment {
+	ret := make([]Assignment, 0)
+	for _, f := range findings {
+		for _, res := range f.Resources {
+			if grouper.HasKey(f, res) {
+				continue
+			}
+			a := Assignment{
+				FindingId:  awssdk.ToString(f.Id),
+				ResourceId: awssdk.ToString(res.Id),
+			}
+			if rule, ok := r.Match(f, res); ok {
+				a.Rule = rule.Name
+				a.Project = rule.Project
+			}
+			ret = append(ret, a)
+		}
+	}
+	return ret
+}
+
+// Overrides returns the projects of the matched rules, by the key of group.OverrideKey
+func Overrides(assignments []Assignment) map[string]string {
+	ret := make(map[string]string, 0)
+	for _, a := range assignments {
+		if a.Rule != "" {
+			ret[group.OverrideKey(a.FindingId, a.ResourceId)] = a.Project
+		}
+	}
+	return ret
+}
+
+// WriteReport writes the assignments to the CSV file
+func WriteReport(path string, assignments []Assignment) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	err = w.Write([]string{"Finding ID", "Resource ID", "Rule", "Project"})
+	if err != nil {
+		return err
+	}
+	for _, a := range assignments {
+		err = w.Write([]string{a.FindingId, a.ResourceId, a.Rule, a.Project})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
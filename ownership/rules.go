@@ -0,0 +1,141 @@
+package ownership
+
+import (
+	"fmt"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/spf13/viper"
+	"regexp"
+	"strings"
+)
+
+// Rule maps the resources to the project. All the given conditions must match.
+type Rule struct {
+	// Name shown in the report, the position of the rule if empty
+	Name string
+	// AWS account ID of the finding
+	AccountId string
+	// Glob pattern of the resource ID, where '*' matches any characters including '/' and ':'
+	Arn string
+	// Regular expression of the resource ID
+	ArnRegex string
+	// Resource type such as 'AwsS3Bucket', matched case-insensitively
+	ResourceType string
+	// Glob pattern of the generator ID of the finding
+	GeneratorId string
+	// Project to which the matched resources belong
+	Project string
+}
+
+// Rules are the ownership rules evaluated in order, the first matched rule wins
+type Rules struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	arn         *regexp.Regexp
+	arnRegex    *regexp.Regexp
+	generatorId *regexp.Regexp
+}
+
+// rulesFile is the layout of the ownership rules file
+type rulesFile struct {
+	Rules []Rule
+}
+
+// LoadRules reads the ownership rules from the YAML or JSON file
+func LoadRules(path string) (*Rules, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+	var file rulesFile
+	err = v.Unmarshal(&file)
+	if err != nil {
+		return nil, err
+	}
+	return NewRules(file.Rules)
+}
+
+// NewRules validates and compiles the rules
+func NewRules(rules []Rule) (*Rules, error) {
+	ret := &Rules{}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule #%d", i+1)
+		}
+		if r.Project == "" {
+			return nil, fmt.Errorf("project is required in ownership %s", r.Name)
+		}
+		if r.AccountId == "" && r.Arn == "" && r.ArnRegex == "" && r.ResourceType == "" && r.GeneratorId == "" {
+			return nil, fmt.Errorf("at least one condition is required in ownership %s", r.Name)
+		}
+		c := compiledRule{Rule: r}
+		var err error
+		if r.Arn != "" {
+			c.arn = globToRegexp(r.Arn)
+		}
+		if r.ArnRegex != "" {
+			c.arnRegex, err = regexp.Compile(r.ArnRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid arnRegex in ownership %s: %w", r.Name, err)
+			}
+		}
+		if r.GeneratorId != "" {
+			c.generatorId = globToRegexp(r.GeneratorId)
+		}
+		ret.rules = append(ret.rules, c)
+	}
+	return ret, nil
+}
+
+// Match returns the first rule matching the resource of the finding
+func (r Rules) Match(f types.AwsSecurityFinding, res types.Resource) (Rule, bool) {
+	for _, c := range r.rules {
+		if c.match(f, res) {
+			return c.Rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (c compiledRule) match(f types.AwsSecurityFinding, res types.Resource) bool {
+	resourceId := awssdk.ToString(res.Id)
+	if c.AccountId != "" && c.AccountId != awssdk.ToString(f.AwsAccountId) {
+		return false
+	}
+	if c.arn != nil && !c.arn.MatchString(resourceId) {
+		return false
+	}
+	if c.arnRegex != nil && !c.arnRegex.MatchString(resourceId) {
+		return false
+	}
+	if c.ResourceType != "" && !strings.EqualFold(c.ResourceType, awssdk.ToString(res.Type)) {
+		return false
+	}
+	if c.generatorId != nil && !c.generatorId.MatchString(awssdk.ToString(f.GeneratorId)) {
+		return false
+	}
+	return true
+}
+
+// globToRegexp converts the glob pattern with '*' and '?' to the regular expression matching the whole string
+func globToRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,120 @@
+package ownership
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/group"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newFinding(accountId string, generatorId string) types.AwsSecurityFinding {
+	return types.AwsSecurityFinding{AwsAccountId: &accountId, GeneratorId: &generatorId}
+}
+
+func newResource(id string, resourceType string) types.Resource {
+	return types.Resource{Id: &id, Type: &resourceType}
+}
+
+func TestMatch(t *testing.T) {
+	rules, err := NewRules([]Rule{
+		{Name: "payment buckets", Arn: "arn:aws:s3:::payment-*", Project: "Payment"},
+		{ArnRegex: `:function:billing-.+$`, Project: "Billing"},
+		{AccountId: "222222222222", ResourceType: "awsec2instance", Project: "Platform"},
+		{GeneratorId: "aws-foundational-security-best-practices/v/1.0.0/IAM.*", Project: "Security"},
+		{AccountId: "111111111111", Project: "Shared"},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		finding  types.AwsSecurityFinding
+		resource types.Resource
+		rule     string
+		project  string
+	}{
+		{newFinding("111111111111", "x"), newResource("arn:aws:s3:::payment-logs", "AwsS3Bucket"), "payment buckets", "Payment"},
+		{newFinding("222222222222", "x"), newResource("arn:aws:lambda:us-east-1:222222222222:function:billing-api", "AwsLambdaFunction"), "rule #2", "Billing"},
+		{newFinding("222222222222", "x"), newResource("arn:aws:ec2:us-east-1:222222222222:instance/i-1", "AwsEc2Instance"), "rule #3", "Platform"},
+		{newFinding("333333333333", "aws-foundational-security-best-practices/v/1.0.0/IAM.1"), newResource("AWS::::Account:333333333333", "AwsAccount"), "rule #4", "Security"},
+		{newFinding("111111111111", "x"), newResource("arn:aws:s3:::other", "AwsS3Bucket"), "rule #5", "Shared"},
+	}
+	for _, tt := range tests {
+		r, ok := rules.Match(tt.finding, tt.resource)
+		assert.True(t, ok)
+		assert.Equal(t, tt.rule, r.Name)
+		assert.Equal(t, tt.project, r.Project)
+	}
+
+	_, ok := rules.Match(newFinding("333333333333", "x"), newResource("arn:aws:s3:::other", "AwsS3Bucket"))
+	assert.False(t, ok)
+}
+
+func TestNewRulesInvalid(t *testing.T) {
+	_, err := NewRules([]Rule{{Arn: "arn:aws:s3:::*"}})
+	assert.Error(t, err)
+	_, err = NewRules([]Rule{{Project: "foo"}})
+	assert.Error(t, err)
+	_, err = NewRules([]Rule{{ArnRegex: "(", Project: "foo"}})
+	assert.Error(t, err)
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ownership.yml")
+	err := os.WriteFile(path, []byte(`
+rules:
+  - name: payment buckets
+    arn: arn:aws:s3:::payment-*
+    project: Payment
+  - accountId: "111111111111"
+    resourceType: AwsS3Bucket
+    project: Shared
+`), 0644)
+	assert.NoError(t, err)
+
+	rules, err := LoadRules(path)
+	assert.NoError(t, err)
+	r, ok := rules.Match(newFinding("111111111111", "x"), newResource("arn:aws:s3:::other", "AwsS3Bucket"))
+	assert.True(t, ok)
+	assert.Equal(t, "Shared", r.Project)
+}
+
+func TestApply(t *testing.T) {
+	rules, err := NewRules([]Rule{
+		{Name: "payment buckets", Arn: "arn:aws:s3:::payment-*", Project: "Payment"},
+	})
+	assert.NoError(t, err)
+	grouper, err := group.NewGrouper("tag:Project|Service", group.Options{IgnoreTagKeyCase: true})
+	assert.NoError(t, err)
+
+	tagged := newResource("arn:aws:s3:::payment-tagged", "AwsS3Bucket")
+	tagged.Tags = map[string]string{"project": "Billing"}
+	findings := []types.AwsSecurityFinding{
+		newFinding("111111111111", "x"),
+		newFinding("111111111111", "x"),
+		newFinding("111111111111", "x"),
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		id := id
+		findings[i].Id = &id
+	}
+	findings[0].Resources = []types.Resource{tagged}
+	findings[1].Resources = []types.Resource{newResource("arn:aws:s3:::payment-logs", "AwsS3Bucket")}
+	findings[2].Resources = []types.Resource{newResource("arn:aws:s3:::other", "AwsS3Bucket")}
+
+	assignments := rules.Apply(findings, *grouper)
+	assert.Len(t, assignments, 2)
+	assert.Equal(t, "payment buckets", assignments[0].Rule)
+	assert.Equal(t, "", assignments[1].Rule)
+	assert.Empty(t, findings[1].Resources[0].Tags)
+
+	overrides := Overrides(assignments)
+	assert.Equal(t, map[string]string{group.OverrideKey("b", "arn:aws:s3:::payment-logs"): "Payment"}, overrides)
+
+	grouper, err = group.NewGrouper("tag:Project|Service", group.Options{IgnoreTagKeyCase: true, Overrides: overrides})
+	assert.NoError(t, err)
+	result := grouper.Group(findings)
+	assert.Equal(t, "a", *result["Billing"][0].Id)
+	assert.Equal(t, "b", *result["Payment"][0].Id)
+	assert.Equal(t, "c", *result[group.NoTagName][0].Id)
+}
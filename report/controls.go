@@ -0,0 +1,166 @@
+package report
+
+import (
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ControlCount is the number of the passed and failed findings of a control
+type ControlCount struct {
+	Passed int
+	Failed int
+}
+
+// Control is a row of the control matrix
+type Control struct {
+	// Security control ID such as 'S3.1'
+	Id    string
+	Title string
+	// Counts of all the projects
+	Total ControlCount
+	// Counts by project
+	Project2Count map[string]ControlCount
+}
+
+// ControlMatrix is the pass/fail counts of the controls of a security standard by project
+type ControlMatrix struct {
+	// Standard ID such as 'standards/aws-foundational-security-best-practices/v/1.0.0'
+	StandardsId string
+	// Projects having the findings of the standard, in the order of SortedProjects
+	Projects []string
+	// Controls in the natural order of their IDs
+	Controls []Control
+}
+
+// Name returns the readable name of the standard like 'aws-foundational-security-best-practices v1.0.0'
+func (m ControlMatrix) Name() string {
+	parts := strings.Split(m.StandardsId, "/")
+	if len(parts) == 4 && parts[2] == "v" {
+		return parts[1] + " v" + parts[3]
+	}
+	return m.StandardsId
+}
+
+// ExcludePassed returns the findings whose compliance status is not PASSED
+func ExcludePassed(findings []shTypes.AwsSecurityFinding) []shTypes.AwsSecurityFinding {
+	ret := make([]shTypes.AwsSecurityFinding, 0)
+	for _, f := range findings {
+		if f.Compliance != nil && f.Compliance.Status == shTypes.ComplianceStatusPassed {
+			continue
+		}
+		ret = append(ret, f)
+	}
+	return ret
+}
+
+// CreateControlMatrices returns the control matrix of each standard whose ID contains any of the standards,
+// counting each finding once by its compliance status. Findings without the security control ID are ignored.
+func CreateControlMatrices(project2Findings map[string][]shTypes.AwsSecurityFinding, standards []string) []ControlMatrix {
+	standard2Controls := make(map[string]map[string]*Control, 0)
+	standard2Projects := make(map[string]map[string]bool, 0)
+	// A finding is in multiple projects or copied for each resource by the multi resource policy,
+	// so the findings counted are kept to count each of them once in the total and in each project
+	counted := make(map[string]bool, 0)
+	countOnce := func(keys ...string) bool {
+		key := strings.Join(keys, "\n")
+		if counted[key] {
+			return false
+		}
+		counted[key] = true
+		return true
+	}
+	for _, project := range SortedProjects(project2Findings) {
+		for _, f := range project2Findings[project] {
+			if f.Compliance == nil || awssdk.ToString(f.Compliance.SecurityControlId) == "" {
+				continue
+			}
+			controlId := *f.Compliance.SecurityControlId
+			for _, s := range f.Compliance.AssociatedStandards {
+				standardsId := awssdk.ToString(s.StandardsId)
+				if !containsAny(standardsId, standards) {
+					continue
+				}
+				if _, ok := standard2Controls[standardsId]; !ok {
+					standard2Controls[standardsId] = make(map[string]*Control, 0)
+					standard2Projects[standardsId] = make(map[string]bool, 0)
+				}
+				c, ok := standard2Controls[standardsId][controlId]
+				if !ok {
+					c = &Control{Id: controlId, Title: awssdk.ToString(f.Title), Project2Count: make(map[string]ControlCount, 0)}
+					standard2Controls[standardsId][controlId] = c
+				}
+				standard2Projects[standardsId][project] = true
+				findingId := awssdk.ToString(f.Id)
+				if !countOnce(standardsId, controlId, project, findingId) {
+					continue
+				}
+				total := countOnce(standardsId, controlId, "", findingId)
+				count := c.Project2Count[project]
+				switch f.Compliance.Status {
+				case shTypes.ComplianceStatusPassed:
+					count.Passed++
+					if total {
+						c.Total.Passed++
+					}
+				case shTypes.ComplianceStatusFailed:
+					count.Failed++
+					if total {
+						c.Total.Failed++
+					}
+				}
+				c.Project2Count[project] = count
+			}
+		}
+	}
+
+	ret := make([]ControlMatrix, 0)
+	for standardsId, controls := range standard2Controls {
+		m := ControlMatrix{StandardsId: standardsId}
+		for _, project := range SortedProjects(project2Findings) {
+			if standard2Projects[standardsId][project] {
+				m.Projects = append(m.Projects, project)
+			}
+		}
+		for _, c := range controls {
+			m.Controls = append(m.Controls, *c)
+		}
+		sort.Slice(m.Controls, func(i, j int) bool {
+			return lessControlId(m.Controls[i].Id, m.Controls[j].Id)
+		})
+		ret = append(ret, m)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name() < ret[j].Name()
+	})
+	return ret
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(strings.ToLower(s), strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lessControlId compares the control IDs by their service and then their numbers, so that 'S3.2' comes before 'S3.10'
+func lessControlId(a string, b string) bool {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
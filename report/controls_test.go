@@ -0,0 +1,96 @@
+package report
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	fsbp = "standards/aws-foundational-security-best-practices/v/1.0.0"
+	cis  = "standards/cis-aws-foundations-benchmark/v/1.4.0"
+)
+
+func newControlFinding(id string, controlId string, status types.ComplianceStatus, standards ...string) types.AwsSecurityFinding {
+	title := controlId + " title"
+	associated := make([]types.AssociatedStandard, 0)
+	for _, s := range standards {
+		s := s
+		associated = append(associated, types.AssociatedStandard{StandardsId: &s})
+	}
+	return types.AwsSecurityFinding{
+		Id:         &id,
+		Title:      &title,
+		Compliance: &types.Compliance{SecurityControlId: &controlId, Status: status, AssociatedStandards: associated},
+	}
+}
+
+func TestCreateControlMatrices(t *testing.T) {
+	project2Findings := map[string][]types.AwsSecurityFinding{
+		"foo": {
+			newControlFinding("id-1", "S3.10", types.ComplianceStatusFailed, fsbp),
+			newControlFinding("id-2", "S3.2", types.ComplianceStatusPassed, fsbp),
+			newControlFinding("id-3", "IAM.1", types.ComplianceStatusFailed, fsbp, cis),
+		},
+		"bar": {
+			newControlFinding("id-4", "S3.2", types.ComplianceStatusFailed, fsbp),
+			newControlFinding("id-5", "S3.2", types.ComplianceStatusPassed, fsbp),
+			newControlFinding("id-6", "S3.2", types.ComplianceStatusWarning, fsbp),
+			{Title: new(string)},
+		},
+	}
+
+	matrices := CreateControlMatrices(project2Findings, []string{"aws-foundational-security-best-practices"})
+	assert.Len(t, matrices, 1)
+	m := matrices[0]
+	assert.Equal(t, "aws-foundational-security-best-practices v1.0.0", m.Name())
+	assert.Equal(t, []string{"bar", "foo"}, m.Projects)
+
+	ids := make([]string, 0)
+	for _, c := range m.Controls {
+		ids = append(ids, c.Id)
+	}
+	assert.Equal(t, []string{"IAM.1", "S3.2", "S3.10"}, ids)
+	assert.Equal(t, "S3.2 title", m.Controls[1].Title)
+	assert.Equal(t, ControlCount{Passed: 2, Failed: 1}, m.Controls[1].Total)
+	assert.Equal(t, ControlCount{Passed: 1, Failed: 1}, m.Controls[1].Project2Count["bar"])
+	assert.Equal(t, ControlCount{Passed: 1}, m.Controls[1].Project2Count["foo"])
+	_, ok := m.Controls[0].Project2Count["bar"]
+	assert.False(t, ok)
+
+	matrices = CreateControlMatrices(project2Findings, []string{"CIS", "aws-foundational"})
+	assert.Len(t, matrices, 2)
+	assert.Equal(t, "cis-aws-foundations-benchmark v1.4.0", matrices[1].Name())
+	assert.Equal(t, []string{"foo"}, matrices[1].Projects)
+
+	assert.Empty(t, CreateControlMatrices(project2Findings, nil))
+}
+
+func TestCreateControlMatricesDuplicates(t *testing.T) {
+	// A finding in multiple projects, and a finding copied for each resource in a project
+	shared := newControlFinding("shared", "S3.1", types.ComplianceStatusFailed, fsbp)
+	copied := newControlFinding("copied", "S3.1", types.ComplianceStatusPassed, fsbp)
+	project2Findings := map[string][]types.AwsSecurityFinding{
+		"foo": {shared, copied, copied},
+		"bar": {shared},
+	}
+
+	matrices := CreateControlMatrices(project2Findings, []string{"aws-foundational-security-best-practices"})
+	assert.Len(t, matrices, 1)
+	c := matrices[0].Controls[0]
+	assert.Equal(t, ControlCount{Passed: 1, Failed: 1}, c.Total)
+	assert.Equal(t, ControlCount{Passed: 1, Failed: 1}, c.Project2Count["foo"])
+	assert.Equal(t, ControlCount{Failed: 1}, c.Project2Count["bar"])
+}
+
+func TestExcludePassed(t *testing.T) {
+	findings := []types.AwsSecurityFinding{
+		newControlFinding("id-7", "S3.1", types.ComplianceStatusFailed, fsbp),
+		newControlFinding("id-8", "S3.2", types.ComplianceStatusPassed, fsbp),
+		{},
+	}
+	result := ExcludePassed(findings)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "S3.1", *result[0].Compliance.SecurityControlId)
+	assert.Nil(t, result[1].Compliance)
+}
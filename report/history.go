@@ -0,0 +1,96 @@
+package report
+
+import (
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"sort"
+	"strconv"
+)
+
+// MaxHistoryChartProjects is the maximum number of the projects in the history chart
+const MaxHistoryChartProjects = 10
+
+// HistoryHeader is the header of the history rows, which have the counts of all the severities
+// so that the columns do not change with the config
+var HistoryHeader = historyHeader()
+
+func historyHeader() []interface{} {
+	ret := []interface{}{"Date", "Project", "Total"}
+	for _, s := range aws.OrderedSeverities {
+		ret = append(ret, string(s))
+	}
+	return ret
+}
+
+// CreateHistoryRows returns the rows of the number of the findings by severity for each project at the date
+func CreateHistoryRows(date string, project2Findings map[string][]shTypes.AwsSecurityFinding) [][]interface{} {
+	ret := make([][]interface{}, 0)
+	for _, project := range SortedProjects(project2Findings) {
+		findings := project2Findings[project]
+		row := []interface{}{date, project, len(findings)}
+		for _, s := range aws.OrderedSeverities {
+			row = append(row, CountBySeverity(findings, s))
+		}
+		ret = append(ret, row)
+	}
+	return ret
+}
+
+// CreateHistoryChartValues returns the table of the total numbers of the findings by date, with a column for each
+// of the top n projects by the total at the last date. Dates are in the order of the history rows.
+func CreateHistoryChartValues(history [][]interface{}, n int) [][]interface{} {
+	dates := make([]string, 0)
+	date2Totals := make(map[string]map[string]int, 0)
+	for _, row := range history {
+		if len(row) < 3 {
+			continue
+		}
+		date := fmt.Sprint(row[0])
+		total, err := strconv.Atoi(fmt.Sprint(row[2]))
+		if err != nil {
+			continue
+		}
+		if _, ok := date2Totals[date]; !ok {
+			dates = append(dates, date)
+			date2Totals[date] = make(map[string]int, 0)
+		}
+		date2Totals[date][fmt.Sprint(row[1])] = total
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	latest := date2Totals[dates[len(dates)-1]]
+	projects := make([]string, 0)
+	for p := range latest {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if latest[projects[i]] != latest[projects[j]] {
+			return latest[projects[i]] > latest[projects[j]]
+		}
+		return projects[i] < projects[j]
+	})
+	if len(projects) > n {
+		projects = projects[:n]
+	}
+
+	header := []interface{}{"Date"}
+	for _, p := range projects {
+		header = append(header, p)
+	}
+	ret := [][]interface{}{header}
+	for _, date := range dates {
+		row := []interface{}{date}
+		for _, p := range projects {
+			if total, ok := date2Totals[date][p]; ok {
+				row = append(row, total)
+			} else {
+				row = append(row, "")
+			}
+		}
+		ret = append(ret, row)
+	}
+	return ret
+}
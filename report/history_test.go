@@ -0,0 +1,39 @@
+package report
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCreateHistoryRows(t *testing.T) {
+	rows := CreateHistoryRows("2023-08-01 00:00", map[string][]types.AwsSecurityFinding{
+		"foo": {
+			{Severity: &types.Severity{Label: types.SeverityLabelHigh}},
+			{Severity: &types.Severity{Label: types.SeverityLabelCritical}},
+		},
+		"bar": {},
+	})
+	assert.Equal(t, [][]interface{}{
+		{"2023-08-01 00:00", "bar", 0, 0, 0, 0, 0, 0},
+		{"2023-08-01 00:00", "foo", 2, 1, 1, 0, 0, 0},
+	}, rows)
+}
+
+func TestCreateHistoryChartValues(t *testing.T) {
+	// Existing rows are read as strings
+	history := [][]interface{}{
+		{"2023-08-01 00:00", "foo", "3"},
+		{"2023-08-01 00:00", "bar", "1"},
+		{"2023-08-02 00:00", "foo", 2},
+		{"2023-08-02 00:00", "baz", 5},
+		{"2023-08-02 00:00", "bar", 2},
+	}
+	assert.Equal(t, [][]interface{}{
+		{"Date", "baz", "bar"},
+		{"2023-08-01 00:00", "", 1},
+		{"2023-08-02 00:00", 5, 2},
+	}, CreateHistoryChartValues(history, 2))
+
+	assert.Nil(t, CreateHistoryChartValues(nil, 2))
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Segment of a field path like 'Resources[0]', or 'Tags' followed by a map key
+var pathSegmentPattern = regexp.MustCompile(`^([^\[\]]+)((?:\[\d+])*)$`)
+
+var findingType = reflect.TypeOf(shTypes.AwsSecurityFinding{})
+
+// pathStep is a struct field or a map key, followed by the slice indices
+type pathStep struct {
+	name    string
+	indices []int
+}
+
+// parsePath parses the field path like 'Resources[0].Tags.Owner'
+func parsePath(path string) ([]pathStep, error) {
+	steps := make([]pathStep, 0)
+	for _, s := range strings.Split(path, ".") {
+		m := pathSegmentPattern.FindStringSubmatch(s)
+		if m == nil {
+			return nil, fmt.Errorf("invalid field path '%s'", path)
+		}
+		step := pathStep{name: m[1]}
+		for _, i := range strings.Split(strings.Trim(m[2], "[]"), "][") {
+			if i == "" {
+				continue
+			}
+			n, _ := strconv.Atoi(i)
+			step.indices = append(step.indices, n)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// validatePath checks that the field path exists in the finding
+func validatePath(path string) error {
+	steps, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	t := findingType
+	for _, s := range steps {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := t.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, s.name) })
+			if !ok {
+				return fmt.Errorf("invalid field path '%s': no field '%s' in %s", path, s.name, t.Name())
+			}
+			t = f.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return fmt.Errorf("invalid field path '%s': '%s' is not a field", path, s.name)
+		}
+		for range s.indices {
+			if t.Kind() != reflect.Slice {
+				return fmt.Errorf("invalid field path '%s': '%s' is not a list", path, s.name)
+			}
+			t = t.Elem()
+		}
+	}
+	return nil
+}
+
+// resolvePath returns the value at the field path of the finding, or nil if any of the fields is missing.
+// Struct fields are matched case-insensitively, and lists and structs are returned as JSON.
+func resolvePath(f shTypes.AwsSecurityFinding, steps []pathStep) interface{} {
+	v := reflect.ValueOf(f)
+	for _, s := range steps {
+		v = reflect.Indirect(v)
+		switch v.Kind() {
+		case reflect.Struct:
+			v = v.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, s.name) })
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(s.name))
+		default:
+			return nil
+		}
+		if !v.IsValid() {
+			return nil
+		}
+		for _, i := range s.indices {
+			if v.Kind() != reflect.Slice || i >= v.Len() {
+				return nil
+			}
+			v = v.Index(i)
+		}
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return v.Interface()
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,56 @@
+package report
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewPathColumn(t *testing.T) {
+	id := "arn:aws:s3:::bucket"
+	controlId := "S3.1"
+	f := types.AwsSecurityFinding{
+		Compliance: &types.Compliance{Status: types.ComplianceStatusFailed, SecurityControlId: &controlId},
+		Resources:  []types.Resource{{Id: &id, Tags: map[string]string{"Owner": "alice"}}},
+		Severity:   &types.Severity{Normalized: 70},
+		Types:      []string{"Software and Configuration Checks"},
+	}
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"Compliance.SecurityControlId", "S3.1"},
+		{"compliance.status", "FAILED"},
+		{"Resources[0].Id", "arn:aws:s3:::bucket"},
+		{"Resources[0].Tags.Owner", "alice"},
+		{"Resources[0].Tags.Project", nil},
+		{"Resources[1].Id", nil},
+		{"Severity.Normalized", int32(70)},
+		{"Remediation.Recommendation.Url", nil},
+		{"Types", `["Software and Configuration Checks"]`},
+	}
+	for _, tt := range tests {
+		c, err := NewPathColumn(tt.path, tt.path)
+		assert.NoError(t, err, tt.path)
+		assert.Equal(t, tt.expected, c.Value(f), tt.path)
+	}
+}
+
+func TestNewPathColumnInvalid(t *testing.T) {
+	for _, path := range []string{"Foo", "Compliance.Foo", "Title.Foo", "Title[0]", "Resources[0]..Id", "Resources[a]"} {
+		_, err := NewPathColumn(path, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestCreateRowValuesDate(t *testing.T) {
+	c, err := NewPathColumn("First observed at", "FirstObservedAt")
+	assert.NoError(t, err)
+	c.Date = true
+
+	firstObservedAt := "2023-08-01T12:34:56.000Z"
+	values := CreateRowValues([]Column{c}, []types.AwsSecurityFinding{{FirstObservedAt: &firstObservedAt}, {}})
+	assert.Equal(t, "2023-08-01", values[1][0])
+	assert.Equal(t, "", values[2][0])
+}
@@ -0,0 +1,281 @@
+package report
+
+import (
+	"fmt"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"golang.org/x/exp/slices"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	base   = "https://%[1]s.console.aws.amazon.com/securityhub/home?region=%[1]s#/findings?search=Id%%3D"
+	prefix = `\operator\:EQUALS\:`
+)
+
+// SortedProjects returns the projects in case-insensitive alphabetical order
+func SortedProjects(project2Findings map[string][]shTypes.AwsSecurityFinding) []string {
+	projects := make([]string, 0)
+	for p := range project2Findings {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i]) < strings.ToLower(projects[j])
+	})
+	return projects
+}
+
+// SortFindings sorts the findings in descending order of severity and updated time
+func SortFindings(findings []shTypes.AwsSecurityFinding) {
+	sort.Slice(findings, func(i, j int) bool {
+		// Descending order of severity
+		if findings[i].Severity.Label != findings[j].Severity.Label {
+			return findings[i].Severity.Normalized > findings[j].Severity.Normalized
+		}
+		// Descending order of updated time
+		t1, err1 := time.Parse(time.RFC3339, *findings[i].UpdatedAt)
+		t2, err2 := time.Parse(time.RFC3339, *findings[j].UpdatedAt)
+		if err1 != nil || err2 != nil {
+			return true
+		}
+		return t1.After(t2)
+	})
+}
+
+// Column is a column of the rows of findings
+type Column struct {
+	// Header of the column
+	Name string
+	// Field is the name of the built-in column such as 'ID', or empty for the column by the field path
+	Field string
+	// Value returns the value of the column for the finding
+	Value func(f shTypes.AwsSecurityFinding) interface{}
+	// Date is true if the value is a timestamp shown only by its date in the sheets
+	Date bool
+	// AutoResize is true if the width of the column is fitted to the values
+	AutoResize bool
+	// Link returns the URI linked from the cell of the finding, or empty if the cell has no link
+	Link func(f shTypes.AwsSecurityFinding) string
+	// Collapsed is true if the column is hidden in a collapsed group in the sheets
+	Collapsed bool
+}
+
+// BuiltinColumns returns the columns available by their names in the config, the first ten of which are
+// the default columns. The account columns show the accounts in the organization by account ID.
+func BuiltinColumns(accounts map[string]aws.OrganizationAccount) []Column {
+	account := func(f shTypes.AwsSecurityFinding) aws.OrganizationAccount {
+		return accounts[awssdk.ToString(f.AwsAccountId)]
+	}
+	ret := []Column{
+		{Name: "ID", Value: func(f shTypes.AwsSecurityFinding) interface{} { return *f.Id }, Link: findingUri},
+		{Name: "Severity", Value: func(f shTypes.AwsSecurityFinding) interface{} { return f.Severity.Label }},
+		{Name: "Title", Value: func(f shTypes.AwsSecurityFinding) interface{} { return *f.Title }, AutoResize: true},
+		{Name: "Resource", Value: func(f shTypes.AwsSecurityFinding) interface{} { return ResourceIds(f) }, AutoResize: true},
+		{Name: "Workflow Status", Value: func(f shTypes.AwsSecurityFinding) interface{} { return f.Workflow.Status }},
+		{Name: "Product Name", Value: func(f shTypes.AwsSecurityFinding) interface{} { return awssdk.ToString(f.ProductName) }},
+		{Name: "Region", Value: func(f shTypes.AwsSecurityFinding) interface{} { return *f.Region }},
+		{Name: "Account ID", Value: func(f shTypes.AwsSecurityFinding) interface{} { return *f.AwsAccountId }},
+		{Name: "Created at", Value: func(f shTypes.AwsSecurityFinding) interface{} { return *f.CreatedAt }, Date: true},
+		{Name: "Updated at", Value: func(f shTypes.AwsSecurityFinding) interface{} { return *f.UpdatedAt }, Date: true},
+		{Name: "Remediation", Value: func(f shTypes.AwsSecurityFinding) interface{} { return remediationText(f) }, Link: remediationUrl},
+		{Name: "Account Name", Value: func(f shTypes.AwsSecurityFinding) interface{} { return account(f).Name }},
+		{Name: "OU Path", Value: func(f shTypes.AwsSecurityFinding) interface{} { return account(f).OuPath }},
+		{Name: "Description", Value: func(f shTypes.AwsSecurityFinding) interface{} { return awssdk.ToString(f.Description) }, Collapsed: true},
+		{Name: "Notes", Value: func(f shTypes.AwsSecurityFinding) interface{} { return noteText(f) }, Collapsed: true},
+	}
+	for i := range ret {
+		ret[i].Field = ret[i].Name
+	}
+	return ret
+}
+
+const defaultColumnCount = 10
+
+// DefaultColumns returns the columns used if not configured
+func DefaultColumns() []Column {
+	return BuiltinColumns(nil)[:defaultColumnCount]
+}
+
+// ColumnIndex returns the index of the built-in column of the field, or -1 if it is not in the columns
+func ColumnIndex(columns []Column, field string) int {
+	return slices.IndexFunc(columns, func(c Column) bool { return c.Field == field })
+}
+
+// ColumnHeader returns the header of the built-in column of the field, or the field itself if it is not in the columns
+func ColumnHeader(columns []Column, field string) string {
+	if i := ColumnIndex(columns, field); i >= 0 {
+		return columns[i].Name
+	}
+	return field
+}
+
+// BuiltinColumn returns the built-in column of the name, matched case-insensitively
+func BuiltinColumn(name string, accounts map[string]aws.OrganizationAccount) (Column, bool) {
+	columns := BuiltinColumns(accounts)
+	i := slices.IndexFunc(columns, func(c Column) bool { return strings.EqualFold(c.Name, name) })
+	if i < 0 {
+		return Column{}, false
+	}
+	return columns[i], true
+}
+
+// NewPathColumn returns the column of the value at the field path of the finding like 'Resources[0].Tags.Owner'
+func NewPathColumn(name string, path string) (Column, error) {
+	err := validatePath(path)
+	if err != nil {
+		return Column{}, err
+	}
+	steps, err := parsePath(path)
+	if err != nil {
+		return Column{}, err
+	}
+	return Column{
+		Name:  name,
+		Value: func(f shTypes.AwsSecurityFinding) interface{} { return resolvePath(f, steps) },
+	}, nil
+}
+
+// AccountTagColumns returns the columns of the tags of the accounts in the organization
+func AccountTagColumns(tagKeys []string, accounts map[string]aws.OrganizationAccount) []Column {
+	ret := make([]Column, 0)
+	for _, k := range tagKeys {
+		k := k
+		ret = append(ret, Column{
+			Name: "Account Tag: " + k,
+			Value: func(f shTypes.AwsSecurityFinding) interface{} {
+				return accounts[awssdk.ToString(f.AwsAccountId)].Tags[k]
+			},
+		})
+	}
+	return ret
+}
+
+// ColumnNames returns the header of the rows of findings
+func ColumnNames(columns []Column) []interface{} {
+	ret := make([]interface{}, 0)
+	for _, c := range columns {
+		ret = append(ret, c.Name)
+	}
+	return ret
+}
+
+// CreateRowValues returns the header and the rows of the findings, with the timestamps truncated to the dates
+// and the missing values as empty
+func CreateRowValues(columns []Column, findings []shTypes.AwsSecurityFinding) (values [][]interface{}) {
+	values = append(values, ColumnNames(columns))
+
+	for _, f := range findings {
+		row := make([]interface{}, 0)
+		for _, c := range columns {
+			v := c.Value(f)
+			switch {
+			case v == nil:
+				v = ""
+			case c.Date:
+				v = strings.Split(fmt.Sprint(v), "T")[0]
+			}
+			row = append(row, v)
+		}
+		values = append(values, row)
+	}
+	return
+}
+
+// CreateRecordValues returns the rows of the findings without any truncation
+func CreateRecordValues(columns []Column, findings []shTypes.AwsSecurityFinding) (values [][]interface{}) {
+	for _, f := range findings {
+		row := make([]interface{}, 0)
+		for _, c := range columns {
+			row = append(row, c.Value(f))
+		}
+		values = append(values, row)
+	}
+	return
+}
+
+// CreateRowLinks returns the links of the cells in the row of the finding, which are empty for the columns without links
+func CreateRowLinks(columns []Column, f shTypes.AwsSecurityFinding) []string {
+	ret := make([]string, 0)
+	for _, c := range columns {
+		link := ""
+		if c.Link != nil {
+			link = c.Link(f)
+		}
+		ret = append(ret, link)
+	}
+	return ret
+}
+
+// ResourceIds returns the IDs of all the resources of the finding, one per line
+func ResourceIds(f shTypes.AwsSecurityFinding) string {
+	ids := make([]string, 0)
+	for _, r := range f.Resources {
+		ids = append(ids, awssdk.ToString(r.Id))
+	}
+	return strings.Join(ids, "\n")
+}
+
+// remediationText returns the recommendation to remediate the finding, or its URL if it has no text
+func remediationText(f shTypes.AwsSecurityFinding) string {
+	if f.Remediation == nil || f.Remediation.Recommendation == nil {
+		return ""
+	}
+	if text := awssdk.ToString(f.Remediation.Recommendation.Text); text != "" {
+		return text
+	}
+	return awssdk.ToString(f.Remediation.Recommendation.Url)
+}
+
+func remediationUrl(f shTypes.AwsSecurityFinding) string {
+	if f.Remediation == nil || f.Remediation.Recommendation == nil {
+		return ""
+	}
+	return awssdk.ToString(f.Remediation.Recommendation.Url)
+}
+
+func noteText(f shTypes.AwsSecurityFinding) string {
+	if f.Note == nil {
+		return ""
+	}
+	return awssdk.ToString(f.Note.Text)
+}
+
+func findingUri(f shTypes.AwsSecurityFinding) string {
+	return CreateUriToFinding(*f.Id, *f.Region)
+}
+
+// CreateUriToFinding returns the URI of the finding in the AWS console
+func CreateUriToFinding(findingID string, region string) string {
+	fstEncoding := url.QueryEscape(prefix + findingID)
+	sndEncoding := url.QueryEscape(fstEncoding)
+	return fmt.Sprintf(base, region) + sndEncoding
+}
+
+// CountDistinctBySeverity returns the number of the findings with the severity in all the projects,
+// counting each finding once even if it is in multiple projects or copied for each resource
+func CountDistinctBySeverity(project2Findings map[string][]shTypes.AwsSecurityFinding, severity aws.Severity) int {
+	counted := make(map[string]bool, 0)
+	for _, findings := range project2Findings {
+		for _, f := range findings {
+			if (string)(f.Severity.Label) == (string)(severity) {
+				counted[awssdk.ToString(f.Id)] = true
+			}
+		}
+	}
+	return len(counted)
+}
+
+// CountBySeverity returns the number of the findings with the severity
+func CountBySeverity(findings []shTypes.AwsSecurityFinding, severity aws.Severity) int {
+	count := 0
+	for _, f := range findings {
+		if (string)(f.Severity.Label) == (string)(severity) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,172 @@
+package sheet
+
+import (
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/group"
+	"github.com/kota65535/securityhub-exporter/report"
+	"golang.org/x/exp/slices"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Annotations holds the values of the user-owned columns, by row key and column name
+type Annotations map[string]map[string]string
+
+// GetAnnotations reads the values of the annotation columns from all the project sheets
+func (r SecurityHubSpreadSheet) GetAnnotations() (Annotations, error) {
+	ret := make(Annotations, 0)
+	if len(r.AnnotationColumns) == 0 {
+		return ret, nil
+	}
+
+	sheetz, err := r.GetProjectSheets()
+	if err != nil {
+		return nil, err
+	}
+	if len(sheetz) == 0 {
+		return ret, nil
+	}
+	titles := make([]string, 0)
+	for _, s := range sheetz {
+		titles = append(titles, s.Properties.Title)
+	}
+	return r.getAnnotations(titles)
+}
+
+func (r SecurityHubSpreadSheet) getAnnotations(titles []string) (Annotations, error) {
+	ranges := make([]string, 0)
+	for _, t := range titles {
+		// Read the whole sheet, since the annotation columns may be anywhere in the previous layout
+		ranges = append(ranges, sheetRange(t))
+	}
+	res, err := Retry(func() (*sheets.BatchGetValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			BatchGet(r.Spreadsheet.SpreadsheetId).
+			Ranges(ranges...).
+			ValueRenderOption("FORMULA").
+			DateTimeRenderOption("FORMATTED_STRING").
+			Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(Annotations, 0)
+	for i, vr := range res.ValueRanges {
+		err = r.parseAnnotations(vr.Values, ret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the annotations of sheet '%s': %w", titles[i], err)
+		}
+	}
+	return ret, nil
+}
+
+// parseAnnotations adds the values of the annotation columns in the rows of a sheet to the annotations.
+// It fails if the sheet has any annotation column but not the columns of the row keys, whose annotations would be lost.
+func (r SecurityHubSpreadSheet) parseAnnotations(values [][]interface{}, annotations Annotations) error {
+	if len(values) == 0 {
+		return nil
+	}
+	// Find the columns by the header, since users may have moved them
+	header := values[0]
+	annotationColumns := make(map[string]int, 0)
+	for _, c := range r.AnnotationColumns {
+		if i := slices.Index(header, interface{}(c)); i >= 0 {
+			annotationColumns[c] = i
+		}
+	}
+	if len(annotationColumns) == 0 {
+		return nil
+	}
+	idColumn := headerColumn(header, r.Columns, "ID")
+	if idColumn < 0 {
+		return fmt.Errorf("column of field 'ID' is not found in the header")
+	}
+	resourceColumn := headerColumn(header, r.Columns, "Resource")
+	if resourceColumn < 0 && r.MultiResourcePolicy == group.MultiResourcePolicyResource {
+		return fmt.Errorf("column of field 'Resource' is not found in the header")
+	}
+
+	for _, row := range values[1:] {
+		if cell(row, idColumn) == "" {
+			continue
+		}
+		key := r.rowKey(row, idColumn, resourceColumn)
+		for c, i := range annotationColumns {
+			v := cell(row, i)
+			if v == "" {
+				continue
+			}
+			if _, ok := annotations[key]; !ok {
+				annotations[key] = make(map[string]string, 0)
+			}
+			annotations[key][c] = v
+		}
+	}
+	return nil
+}
+
+// headerColumn returns the index of the column of the field in the header, matched by the header in the columns
+// or by the field itself, which is the header of the built-in column by default
+func headerColumn(header []interface{}, columns []report.Column, field string) int {
+	if i := slices.Index(header, interface{}(report.ColumnHeader(columns, field))); i >= 0 {
+		return i
+	}
+	return slices.Index(header, interface{}(field))
+}
+
+// updateAnnotationHeader writes the header of the annotation columns next to the columns of the exporter
+func (r SecurityHubSpreadSheet) updateAnnotationHeader(title string) error {
+	if len(r.AnnotationColumns) == 0 {
+		return nil
+	}
+	header := make([]interface{}, 0)
+	for _, c := range r.AnnotationColumns {
+		header = append(header, c)
+	}
+	_, err := Retry(func() (*sheets.UpdateValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			Update(r.Spreadsheet.SpreadsheetId, a1Range(title, columnLetter(len(report.ColumnNames(r.Columns)))+"1"), &sheets.ValueRange{
+				Values: [][]interface{}{header},
+			}).
+			ValueInputOption("RAW").
+			Do()
+	})
+	return err
+}
+
+// restoreAnnotations writes the annotations to the rows of the findings, which must be in the same order as the sheet.
+// All the rows are written, so that no stale values are left in the annotation columns.
+func (r SecurityHubSpreadSheet) restoreAnnotations(title string, findings []shTypes.AwsSecurityFinding, annotations Annotations) error {
+	if len(r.AnnotationColumns) == 0 || len(findings) == 0 {
+		return nil
+	}
+
+	writeRange := a1Range(title, fmt.Sprintf("%s2", columnLetter(len(report.ColumnNames(r.Columns)))))
+	_, err := Retry(func() (*sheets.UpdateValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			Update(r.Spreadsheet.SpreadsheetId, writeRange, &sheets.ValueRange{Values: r.annotationValues(findings, annotations)}).
+			ValueInputOption("USER_ENTERED").
+			Do()
+	})
+	return err
+}
+
+// annotationValues returns the values of the annotation columns in the rows of the findings
+func (r SecurityHubSpreadSheet) annotationValues(findings []shTypes.AwsSecurityFinding, annotations Annotations) [][]interface{} {
+	values := make([][]interface{}, 0)
+	for _, f := range findings {
+		values = append(values, r.annotationRow(annotations, r.findingKey(f)))
+	}
+	return values
+}
+
+// annotationRow returns the values of the annotation columns in the row of the key
+func (r SecurityHubSpreadSheet) annotationRow(annotations Annotations, key string) []interface{} {
+	row := make([]interface{}, 0)
+	a := annotations[key]
+	for _, c := range r.AnnotationColumns {
+		row = append(row, a[c])
+	}
+	return row
+}
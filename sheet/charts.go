@@ -0,0 +1,58 @@
+package sheet
+
+import (
+	"google.golang.org/api/sheets/v4"
+)
+
+// chartRequest returns the request to update the spec of the chart with the same title in the sheet,
+// or to add the chart at the position if it does not exist, so that the charts are updated in place
+func chartRequest(s *sheets.Sheet, spec *sheets.ChartSpec, position *sheets.EmbeddedObjectPosition) *sheets.Request {
+	for _, c := range s.Charts {
+		if c.Spec != nil && c.Spec.Title == spec.Title {
+			return &sheets.Request{
+				UpdateChartSpec: &sheets.UpdateChartSpecRequest{
+					ChartId: c.ChartId,
+					Spec:    spec,
+				},
+			}
+		}
+	}
+	return &sheets.Request{
+		AddChart: &sheets.AddChartRequest{
+			Chart: &sheets.EmbeddedChart{
+				Spec:     spec,
+				Position: position,
+			},
+		},
+	}
+}
+
+// overlayPosition returns the position of the chart floating over the cell of the sheet
+func overlayPosition(sheetId int64, rowIndex int64, columnIndex int64) *sheets.EmbeddedObjectPosition {
+	return &sheets.EmbeddedObjectPosition{
+		OverlayPosition: &sheets.OverlayPosition{
+			AnchorCell: &sheets.GridCoordinate{
+				SheetId:     sheetId,
+				RowIndex:    rowIndex,
+				ColumnIndex: columnIndex,
+			},
+		},
+	}
+}
+
+// chartData returns the data of the chart in the column range of the rows
+func chartData(sheetId int64, startRow int64, endRow int64, column int64) *sheets.ChartData {
+	return &sheets.ChartData{
+		SourceRange: &sheets.ChartSourceRange{
+			Sources: []*sheets.GridRange{
+				{
+					SheetId:          sheetId,
+					StartRowIndex:    startRow,
+					EndRowIndex:      endRow,
+					StartColumnIndex: column,
+					EndColumnIndex:   column + 1,
+				},
+			},
+		},
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"google.golang.org/api/sheets/v4"
 )
 
-func (r SecurityHubSpreadSheet) DeleteAllSheets(exceptSheetTitles []string) error {
-	sheetz, err := r.GetAllSheets(exceptSheetTitles)
+// DeleteProjectSheets deletes all the project sheets, keeping the other sheets such as the index sheet, the control sheets and the history sheet
+func (r SecurityHubSpreadSheet) DeleteProjectSheets() error {
+	sheetz, err := r.GetProjectSheets()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,90 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"log"
+)
+
+func (r SecurityHubSpreadSheet) Name() string {
+	return "Google Sheet"
+}
+
+// Export updates the project sheets and the index sheet
+func (r SecurityHubSpreadSheet) Export(_ context.Context, project2Findings exporter.Project2Findings, metadata exporter.Metadata) error {
+	switch r.SheetUpdateMode {
+	case "", cfg.SheetUpdateModeRecreate:
+		log.Println("Reading annotations...")
+		annotations, err := r.GetAnnotations()
+		if err != nil {
+			return err
+		}
+		log.Printf("Got annotations for %d findings\n", len(annotations))
+
+		log.Println("Deleting existing sheets...")
+		err = r.DeleteProjectSheets()
+		if err != nil {
+			return err
+		}
+
+		log.Println("Updating sheets...")
+		err = r.UpdateSheets(project2Findings, annotations)
+		if err != nil {
+			return err
+		}
+	case cfg.SheetUpdateModeSync:
+		log.Println("Syncing sheets...")
+		err := r.SyncSheets(project2Findings)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown sheet update mode '%s'", r.SheetUpdateMode)
+	}
+
+	log.Println("Updating index sheets...")
+	err := r.UpdateIndexSheet(project2Findings, metadata.Insights)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Updating control sheets...")
+	err = r.UpdateControlSheets(metadata.ControlProject2Findings)
+	if err != nil {
+		return err
+	}
+
+	// History rows are dated by the fetch time, which is unknown for the findings loaded from an ASFF file
+	switch {
+	case r.HistorySheetName == "":
+	case metadata.FetchedAt.IsZero():
+		log.Println("Skipping history sheet since the fetch time of the findings is unknown")
+	default:
+		log.Println("Updating history sheet...")
+		err = r.UpdateHistorySheet(project2Findings, metadata.Insights, metadata.FetchedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Println("Click the link below to see the result:")
+	log.Println("https://docs.google.com/spreadsheets/d/" + r.Spreadsheet.SpreadsheetId)
+	return nil
+}
+
+// projectsWithoutInsights returns the findings by project without the projects of the insights,
+// since the findings of the insights are also in the projects
+func projectsWithoutInsights(project2Findings map[string][]shTypes.AwsSecurityFinding, insights []aws.Insight) map[string][]shTypes.AwsSecurityFinding {
+	ret := make(map[string][]shTypes.AwsSecurityFinding, 0)
+	for p, findings := range project2Findings {
+		ret[p] = findings
+	}
+	for _, i := range insights {
+		delete(ret, exporter.InsightProjectName(i.Name))
+	}
+	return ret
+}
@@ -2,6 +2,7 @@ package sheet
 
 import (
 	"fmt"
+	"github.com/kota65535/securityhub-exporter/report"
 	"golang.org/x/exp/slices"
 	"google.golang.org/api/sheets/v4"
 )
@@ -25,6 +26,107 @@ func (r SecurityHubSpreadSheet) GetAllSheets(exceptSheetTitles []string) ([]*she
 	return ret, nil
 }
 
+// GetProjectSheets returns the project sheets, which are the sheets created by the exporter except the index sheet.
+// Sheets are identified positively, so that the control sheets, the history sheet and the sheets added by users
+// are never deleted or cleared even if they are not configured anymore.
+func (r SecurityHubSpreadSheet) GetProjectSheets() ([]*sheets.Sheet, error) {
+	sheetz, err := r.GetAllSheets([]string{r.IndexSheetName})
+	if err != nil {
+		return nil, err
+	}
+	ret, _, err := r.splitOwnedSheets(sheetz)
+	return ret, err
+}
+
+// ProjectSheetMetadataKey is the key of the developer metadata marking the project sheets created by the exporter
+const ProjectSheetMetadataKey = "securityhub-exporter.project"
+
+// splitOwnedSheets splits the sheets into those created by the exporter and the others such as those added by users.
+// The sheets created by the exporter have the developer metadata, or the header of the columns if created before it was added.
+func (r SecurityHubSpreadSheet) splitOwnedSheets(sheetz []*sheets.Sheet) ([]*sheets.Sheet, []*sheets.Sheet, error) {
+	unknown := make([]*sheets.Sheet, 0)
+	for _, s := range sheetz {
+		if !hasProjectSheetMetadata(s) {
+			unknown = append(unknown, s)
+		}
+	}
+	ownedIds := make(map[int64]bool, 0)
+	if len(unknown) > 0 {
+		ranges := make([]string, 0)
+		for _, s := range unknown {
+			ranges = append(ranges, a1Range(s.Properties.Title, "1:1"))
+		}
+		res, err := Retry(func() (*sheets.BatchGetValuesResponse, error) {
+			return r.Service.Spreadsheets.Values.
+				BatchGet(r.Spreadsheet.SpreadsheetId).
+				Ranges(ranges...).
+				Do()
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, vr := range res.ValueRanges {
+			if len(vr.Values) > 0 && equalRow(vr.Values[0], report.ColumnNames(r.Columns)) {
+				ownedIds[unknown[i].Properties.SheetId] = true
+			}
+		}
+	}
+
+	// Keep the order of the sheets
+	owned := make([]*sheets.Sheet, 0)
+	others := make([]*sheets.Sheet, 0)
+	for _, s := range sheetz {
+		if hasProjectSheetMetadata(s) || ownedIds[s.Properties.SheetId] {
+			owned = append(owned, s)
+		} else {
+			others = append(others, s)
+		}
+	}
+	return owned, others, nil
+}
+
+func hasProjectSheetMetadata(s *sheets.Sheet) bool {
+	return hasSheetMetadata(s, ProjectSheetMetadataKey)
+}
+
+// hasSheetMetadata returns whether the sheet has the developer metadata of the key
+func hasSheetMetadata(s *sheets.Sheet, key string) bool {
+	return slices.ContainsFunc(s.DeveloperMetadata, func(m *sheets.DeveloperMetadata) bool {
+		return m.MetadataKey == key
+	})
+}
+
+// markProjectSheets adds the developer metadata to the sheets, so that they are known to be created by the exporter
+// even after the columns are changed
+func (r SecurityHubSpreadSheet) markProjectSheets(sheetIds []int64) error {
+	return r.markSheets(sheetIds, ProjectSheetMetadataKey)
+}
+
+// markSheets adds the developer metadata of the key to the sheets
+func (r SecurityHubSpreadSheet) markSheets(sheetIds []int64, key string) error {
+	if len(sheetIds) == 0 {
+		return nil
+	}
+	requests := make([]*sheets.Request, 0)
+	for _, id := range sheetIds {
+		requests = append(requests, &sheets.Request{
+			CreateDeveloperMetadata: &sheets.CreateDeveloperMetadataRequest{
+				DeveloperMetadata: &sheets.DeveloperMetadata{
+					MetadataKey: key,
+					Location:    &sheets.DeveloperMetadataLocation{SheetId: id},
+					Visibility:  "DOCUMENT",
+				},
+			},
+		})
+	}
+	_, err := Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+		return r.Service.Spreadsheets.
+			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
+			Do()
+	})
+	return err
+}
+
 func (r SecurityHubSpreadSheet) GetSheet(name string) (*sheets.Sheet, error) {
 	res, err := Retry(func() (*sheets.Spreadsheet, error) {
 		return r.Service.Spreadsheets.
@@ -0,0 +1,99 @@
+package sheet
+
+import (
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/report"
+	"golang.org/x/exp/slices"
+	"google.golang.org/api/sheets/v4"
+)
+
+// ExportedWorkflowStatusColumn is the header of the collapsed column keeping the workflow status at the export,
+// by which the import tells the edits of users from the changes in Security Hub after the export
+const ExportedWorkflowStatusColumn = "Exported Workflow Status"
+
+// FindingRow is the values of a finding row in a project sheet, which users may have edited
+type FindingRow struct {
+	Sheet          string
+	Id             string
+	WorkflowStatus string
+	// Workflow status at the export, empty if unknown such as the resolved rows
+	ExportedWorkflowStatus string
+	Note                   string
+}
+
+// withExportedWorkflowStatusColumn returns the columns followed by the exported workflow status column,
+// if they have the workflow status column
+func withExportedWorkflowStatusColumn(columns []report.Column) []report.Column {
+	if report.ColumnIndex(columns, "Workflow Status") < 0 {
+		return columns
+	}
+	return append(slices.Clone(columns), report.Column{
+		Name:  ExportedWorkflowStatusColumn,
+		Field: ExportedWorkflowStatusColumn,
+		Value: func(f shTypes.AwsSecurityFinding) interface{} {
+			if f.Workflow == nil {
+				return ""
+			}
+			return f.Workflow.Status
+		},
+		Collapsed: true,
+	})
+}
+
+// GetFindingRows reads the finding rows from all the project sheets.
+// The note is read from the column with the header of noteColumn if given.
+func (r SecurityHubSpreadSheet) GetFindingRows(noteColumn string) ([]FindingRow, error) {
+	sheetz, err := r.GetProjectSheets()
+	if err != nil {
+		return nil, err
+	}
+	if len(sheetz) == 0 {
+		return nil, nil
+	}
+
+	ranges := make([]string, 0)
+	for _, s := range sheetz {
+		ranges = append(ranges, a1Range(s.Properties.Title, "A:"+columnLetter(len(report.ColumnNames(r.Columns))+len(r.AnnotationColumns)-1)))
+	}
+	res, err := Retry(func() (*sheets.BatchGetValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			BatchGet(r.Spreadsheet.SpreadsheetId).
+			Ranges(ranges...).
+			Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]FindingRow, 0)
+	for i, vr := range res.ValueRanges {
+		if len(vr.Values) == 0 {
+			continue
+		}
+		header := vr.Values[0]
+		idColumn := slices.Index(header, interface{}(report.ColumnHeader(r.Columns, "ID")))
+		statusColumn := slices.Index(header, interface{}(report.ColumnHeader(r.Columns, "Workflow Status")))
+		exportedStatusColumn := slices.Index(header, interface{}(ExportedWorkflowStatusColumn))
+		if idColumn < 0 || statusColumn < 0 {
+			continue
+		}
+		noteColumnIndex := -1
+		if noteColumn != "" {
+			noteColumnIndex = slices.Index(header, interface{}(noteColumn))
+		}
+		for _, row := range vr.Values[1:] {
+			id := cell(row, idColumn)
+			if id == "" {
+				continue
+			}
+			ret = append(ret, FindingRow{
+				Sheet:                  sheetz[i].Properties.Title,
+				Id:                     id,
+				WorkflowStatus:         cell(row, statusColumn),
+				ExportedWorkflowStatus: cell(row, exportedStatusColumn),
+				Note:                   cell(row, noteColumnIndex),
+			})
+		}
+	}
+	return ret, nil
+}
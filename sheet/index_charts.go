@@ -0,0 +1,100 @@
+package sheet
+
+import (
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/report"
+	"google.golang.org/api/sheets/v4"
+	"strings"
+)
+
+const (
+	severityChartTitle     = "Findings by severity per project"
+	distributionChartTitle = "Severity distribution"
+)
+
+// getIndexChartRequests returns the requests to write the table of the number of the findings by severity
+// next to the project rows, and to add or update the charts of the project rows and the table.
+// The project rows are the first rows after the header, without the sheets of the insights.
+func (r SecurityHubSpreadSheet) getIndexChartRequests(s *sheets.Sheet, projectRows int, project2Findings map[string][]shTypes.AwsSecurityFinding, insights []aws.Insight) []*sheets.Request {
+	sheetId := s.Properties.SheetId
+	// Columns of the project, the number of the findings and the severities, followed by an empty column
+	totalsColumn := int64(2 + len(r.Severities) + 1)
+
+	projects := projectsWithoutInsights(project2Findings, insights)
+	bold := &sheets.CellFormat{TextFormat: &sheets.TextFormat{Bold: true}}
+	rows := []*sheets.RowData{{Values: []*sheets.CellData{stringCell("Severity", bold), stringCell("Findings", bold)}}}
+	for _, severity := range r.Severities {
+		count := report.CountDistinctBySeverity(projects, severity)
+		rows = append(rows, &sheets.RowData{Values: []*sheets.CellData{stringCell(string(severity), nil), numberCell(count)}})
+	}
+	requests := []*sheets.Request{
+		{
+			UpdateCells: &sheets.UpdateCellsRequest{
+				Rows: rows,
+				Start: &sheets.GridCoordinate{
+					SheetId:     sheetId,
+					RowIndex:    0,
+					ColumnIndex: totalsColumn,
+				},
+				Fields: "userEnteredValue,userEnteredFormat",
+			},
+		},
+	}
+	if projectRows == 0 {
+		return requests
+	}
+
+	// Stacked bar of the severity columns of the project rows
+	endRow := int64(projectRows + 1)
+	series := make([]*sheets.BasicChartSeries, 0)
+	for i, severity := range r.Severities {
+		chartSeries := &sheets.BasicChartSeries{
+			Series:     chartData(sheetId, 0, endRow, int64(2+i)),
+			TargetAxis: "BOTTOM_AXIS",
+		}
+		if c, ok := r.Colors[strings.ToUpper(string(severity))]; ok {
+			c := c
+			chartSeries.ColorStyle = &sheets.ColorStyle{RgbColor: &c}
+		}
+		series = append(series, chartSeries)
+	}
+	severitySpec := &sheets.ChartSpec{
+		Title: severityChartTitle,
+		BasicChart: &sheets.BasicChartSpec{
+			ChartType:      "BAR",
+			StackedType:    "STACKED",
+			LegendPosition: "BOTTOM_LEGEND",
+			HeaderCount:    1,
+			Axis: []*sheets.BasicChartAxis{
+				{Position: "BOTTOM_AXIS", Title: "Findings"},
+				{Position: "LEFT_AXIS", Title: "Project"},
+			},
+			Domains: []*sheets.BasicChartDomain{
+				{Domain: chartData(sheetId, 0, endRow, 0)},
+			},
+			Series: series,
+		},
+	}
+	requests = append(requests, chartRequest(s, severitySpec, overlayPosition(sheetId, 0, totalsColumn+3)))
+
+	// Pie of the table, whose slices cannot be colored by the API
+	distributionSpec := &sheets.ChartSpec{
+		Title: distributionChartTitle,
+		PieChart: &sheets.PieChartSpec{
+			Domain:         chartData(sheetId, 1, int64(len(rows)), totalsColumn),
+			Series:         chartData(sheetId, 1, int64(len(rows)), totalsColumn+1),
+			LegendPosition: "RIGHT_LEGEND",
+		},
+	}
+	requests = append(requests, chartRequest(s, distributionSpec, overlayPosition(sheetId, 20, totalsColumn+3)))
+
+	return requests
+}
+
+func numberCell(value int) *sheets.CellData {
+	v := float64(value)
+	return &sheets.CellData{
+		UserEnteredValue: &sheets.ExtendedValue{NumberValue: &v},
+	}
+}
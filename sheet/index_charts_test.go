@@ -0,0 +1,58 @@
+package sheet
+
+import (
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/sheets/v4"
+	"testing"
+)
+
+func TestGetIndexChartRequests(t *testing.T) {
+	finding := func(id string, severity shTypes.SeverityLabel) shTypes.AwsSecurityFinding {
+		return shTypes.AwsSecurityFinding{Id: &id, Severity: &shTypes.Severity{Label: severity}}
+	}
+	high := sheets.Color{Red: 1}
+	r := SecurityHubSpreadSheet{
+		Severities: []aws.Severity{"CRITICAL", "HIGH"},
+		Colors:     map[string]sheets.Color{"HIGH": high},
+	}
+	insights := []aws.Insight{{Name: "public"}}
+	project2Findings := map[string][]shTypes.AwsSecurityFinding{
+		"foo": {finding("a", shTypes.SeverityLabelCritical), finding("b", shTypes.SeverityLabelHigh), finding("c", shTypes.SeverityLabelHigh)},
+		// The finding with the resources of both projects
+		"bar": {finding("c", shTypes.SeverityLabelHigh), finding("d", shTypes.SeverityLabelHigh)},
+		// Also in the projects
+		exporter.InsightProjectName("public"): {finding("e", shTypes.SeverityLabelHigh)},
+	}
+	// The distribution chart exists already
+	s := &sheets.Sheet{
+		Properties: &sheets.SheetProperties{SheetId: 0},
+		Charts:     []*sheets.EmbeddedChart{{ChartId: 10, Spec: &sheets.ChartSpec{Title: distributionChartTitle}}},
+	}
+	requests := r.getIndexChartRequests(s, 2, project2Findings, insights)
+	assert.Len(t, requests, 3)
+
+	// Totals without the findings of the insights, counting each finding once
+	totals := requests[0].UpdateCells
+	assert.Equal(t, int64(5), totals.Start.ColumnIndex)
+	assert.Len(t, totals.Rows, 3)
+	assert.Equal(t, "HIGH", *totals.Rows[2].Values[0].UserEnteredValue.StringValue)
+	assert.Equal(t, 3.0, *totals.Rows[2].Values[1].UserEnteredValue.NumberValue)
+
+	// Bar chart of the project rows only, without the rows of the insight sheets after them
+	bar := requests[1].AddChart.Chart.Spec.BasicChart
+	assert.Equal(t, int64(3), bar.Domains[0].Domain.SourceRange.Sources[0].EndRowIndex)
+	assert.Len(t, bar.Series, 2)
+	assert.Equal(t, int64(3), bar.Series[1].Series.SourceRange.Sources[0].StartColumnIndex)
+	assert.Equal(t, &high, bar.Series[1].ColorStyle.RgbColor)
+	assert.Nil(t, bar.Series[0].ColorStyle)
+
+	pie := requests[2].UpdateChartSpec
+	assert.Equal(t, int64(10), pie.ChartId)
+	assert.Equal(t, int64(3), pie.Spec.PieChart.Series.SourceRange.Sources[0].EndRowIndex)
+
+	// No charts without the project rows
+	assert.Len(t, r.getIndexChartRequests(s, 0, project2Findings, insights), 1)
+}
@@ -2,29 +2,40 @@ package sheet
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"github.com/kota65535/securityhub-exporter/aws"
 	"github.com/kota65535/securityhub-exporter/cfg"
-	"golang.org/x/image/colornames"
+	"github.com/kota65535/securityhub-exporter/report"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
-	"image/color"
 	"strings"
 )
 
 type SecurityHubSpreadSheet struct {
-	Service        *sheets.Service
-	Spreadsheet    *sheets.Spreadsheet
-	Severities     []aws.Severity
-	Colors         map[string]sheets.Color
-	IndexSheetName string
-	GroupByTag     string
+	Service           *sheets.Service
+	Spreadsheet       *sheets.Spreadsheet
+	Severities        []aws.Severity
+	Colors            map[string]sheets.Color
+	IndexSheetName    string
+	GroupByTag        []string
+	SheetUpdateMode   string
+	ResolvedRowAction string
+	AnnotationColumns []string
+	// Policy of the findings with multiple resources, by which the rows are identified
+	MultiResourcePolicy string
+	// Standards whose controls are shown in the control sheets
+	ControlStandards []string
+	// Sheet of the history of the number of the findings, no history if empty
+	HistorySheetName string
+	// Number of the top projects in the history chart
+	HistoryChartProjects int
+	// Columns of the rows of findings
+	Columns []report.Column
 }
 
-func NewSpreadSheet(config cfg.Config) (*SecurityHubSpreadSheet, error) {
-	ret := &SecurityHubSpreadSheet{}
+func NewSpreadSheet(config cfg.Config, columns []report.Column) (*SecurityHubSpreadSheet, error) {
+	ret := &SecurityHubSpreadSheet{Columns: withExportedWorkflowStatusColumn(columns)}
 
 	ret.Severities = config.Severities
 	ret.Colors = make(map[string]sheets.Color, 0)
@@ -37,6 +48,16 @@ func NewSpreadSheet(config cfg.Config) (*SecurityHubSpreadSheet, error) {
 	}
 	ret.IndexSheetName = config.IndexSheetName
 	ret.GroupByTag = config.GroupByTag
+	ret.SheetUpdateMode = config.SheetUpdateMode
+	ret.ResolvedRowAction = config.ResolvedRowAction
+	ret.AnnotationColumns = config.AnnotationColumns
+	ret.MultiResourcePolicy = config.MultiResourcePolicy
+	ret.ControlStandards = config.ControlStandards
+	ret.HistorySheetName = config.HistorySheetName
+	ret.HistoryChartProjects = config.HistoryChartProjects
+	if ret.HistoryChartProjects == 0 {
+		ret.HistoryChartProjects = 5
+	}
 
 	ctx := context.Background()
 
@@ -179,13 +200,9 @@ func getIndexSheetColumnCreationRequest(config cfg.Config) *sheets.Request {
 }
 
 func toSheetsColor(colorName string) (*sheets.Color, error) {
-	c, ok := colornames.Map[colorName]
-	if !ok {
-		var err error
-		c, err = parseHexColor(colorName)
-		if err != nil {
-			return nil, err
-		}
+	c, err := report.ParseColor(colorName)
+	if err != nil {
+		return nil, err
 	}
 	return &sheets.Color{
 		Alpha: float64(c.A) / 255,
@@ -194,40 +211,3 @@ func toSheetsColor(colorName string) (*sheets.Color, error) {
 		Red:   float64(c.R) / 255,
 	}, nil
 }
-
-var errInvalidFormat = errors.New("invalid format")
-
-func parseHexColor(s string) (c color.RGBA, err error) {
-	c.A = 0xff
-
-	if s[0] != '#' {
-		return c, errInvalidFormat
-	}
-
-	hexToByte := func(b byte) byte {
-		switch {
-		case b >= '0' && b <= '9':
-			return b - '0'
-		case b >= 'a' && b <= 'f':
-			return b - 'a' + 10
-		case b >= 'A' && b <= 'F':
-			return b - 'A' + 10
-		}
-		err = errInvalidFormat
-		return 0
-	}
-
-	switch len(s) {
-	case 7:
-		c.R = hexToByte(s[1])<<4 + hexToByte(s[2])
-		c.G = hexToByte(s[3])<<4 + hexToByte(s[4])
-		c.B = hexToByte(s[5])<<4 + hexToByte(s[6])
-	case 4:
-		c.R = hexToByte(s[1]) * 17
-		c.G = hexToByte(s[2]) * 17
-		c.B = hexToByte(s[3]) * 17
-	default:
-		err = errInvalidFormat
-	}
-	return c, err
-}
@@ -0,0 +1,444 @@
+package sheet
+
+import (
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/kota65535/securityhub-exporter/group"
+	"github.com/kota65535/securityhub-exporter/report"
+	"google.golang.org/api/sheets/v4"
+	"log"
+	"strings"
+)
+
+// syncRow is a data row of a project sheet after the synchronization
+type syncRow struct {
+	values   []interface{}
+	resolved bool
+	// Rows without the finding ID, such as those added by users, which are left as they are
+	blank bool
+	// Links of the cells by column
+	links []string
+}
+
+// syncDiff is the changes of the rows of a project sheet to synchronize it with the findings
+type syncDiff struct {
+	// Values of the updated and the appended rows
+	updates []*sheets.ValueRange
+	// Values of the annotation columns of the appended rows, which may have been in another project sheet
+	annotationUpdates []*sheets.ValueRange
+	// Indices of the rows to delete, in ascending order
+	deletedRows []int64
+	// Data rows after the synchronization
+	rows     []syncRow
+	added    int
+	updated  int
+	resolved int
+}
+
+// SyncSheets updates the project sheets in place instead of recreating them, so that the sheet IDs are kept.
+// Rows are matched with findings by the finding ID, and the annotation columns are left untouched.
+func (r SecurityHubSpreadSheet) SyncSheets(project2Findings map[string][]shTypes.AwsSecurityFinding) error {
+	all, err := r.GetAllSheets([]string{r.IndexSheetName})
+	if err != nil {
+		return err
+	}
+	// Sheets not created by the exporter are never updated, since their contents would be cleared
+	sheetz, others, err := r.splitOwnedSheets(all)
+	if err != nil {
+		return err
+	}
+	title2Other := make(map[string]*sheets.Sheet, 0)
+	for _, s := range others {
+		title := s.Properties.Title
+		if title != r.HistorySheetName && !strings.HasPrefix(title, ControlSheetPrefix) {
+			log.Printf("Skipping sheet '%s' which was not created by the exporter", title)
+		}
+		title2Other[title] = s
+	}
+	unmarked := make([]int64, 0)
+	title2Sheet := make(map[string]*sheets.Sheet, 0)
+	for _, s := range sheetz {
+		title2Sheet[s.Properties.Title] = s
+		if !hasProjectSheetMetadata(s) {
+			unmarked = append(unmarked, s.Properties.SheetId)
+		}
+	}
+	err = r.markProjectSheets(unmarked)
+	if err != nil {
+		return err
+	}
+
+	// Annotations are read from all the sheets at first, since findings may move to another project sheet
+	annotations := make(Annotations, 0)
+	if len(r.AnnotationColumns) > 0 && len(sheetz) > 0 {
+		titles := make([]string, 0)
+		for _, s := range sheetz {
+			titles = append(titles, s.Properties.Title)
+		}
+		annotations, err = r.getAnnotations(titles)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, project := range report.SortedProjects(project2Findings) {
+		findings := project2Findings[project]
+		report.SortFindings(findings)
+
+		if _, ok := title2Other[project]; ok {
+			return fmt.Errorf("sheet '%s' already exists but was not created by the exporter", project)
+		}
+		s, ok := title2Sheet[project]
+		if !ok {
+			log.Printf("Creating sheet for '%s'...", project)
+			err = r.createProjectSheet(project, findings)
+			if err != nil {
+				return err
+			}
+			// Findings may have moved from another project sheet with their annotations
+			err = r.restoreAnnotations(project, findings, annotations)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		log.Printf("Syncing sheet for '%s'...", project)
+		err = r.syncSheet(s, findings, annotations)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Projects without findings anymore keep their sheets, with all the rows resolved
+	for _, s := range sheetz {
+		if _, ok := project2Findings[s.Properties.Title]; ok {
+			continue
+		}
+		log.Printf("Syncing sheet for '%s' which has no findings...", s.Properties.Title)
+		err = r.syncSheet(s, nil, annotations)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r SecurityHubSpreadSheet) syncSheet(s *sheets.Sheet, findings []shTypes.AwsSecurityFinding, annotations Annotations) error {
+	title := s.Properties.Title
+	sheetId := s.Properties.SheetId
+	lastColumn := columnLetter(len(report.ColumnNames(r.Columns)) - 1)
+
+	res, err := Retry(func() (*sheets.ValueRange, error) {
+		return r.Service.Spreadsheets.Values.
+			Get(r.Spreadsheet.SpreadsheetId, a1Range(title, "A1:"+lastColumn)).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+
+	newValues := report.CreateRowValues(r.Columns, findings)
+
+	// Rewrite the whole sheet if its layout is unknown
+	if len(res.Values) == 0 || !equalRow(res.Values[0], report.ColumnNames(r.Columns)) {
+		log.Printf("  header of sheet '%s' does not match, rewriting all rows", title)
+		// Read the whole sheet, since the annotation columns are placed after the columns of the previous layout.
+		// Their values have been read with those of the other sheets.
+		all, err := Retry(func() (*sheets.ValueRange, error) {
+			return r.Service.Spreadsheets.Values.
+				Get(r.Spreadsheet.SpreadsheetId, sheetRange(title)).
+				ValueRenderOption("FORMULA").
+				DateTimeRenderOption("FORMATTED_STRING").
+				Do()
+		})
+		if err != nil {
+			return err
+		}
+
+		// Clear all the columns of the previous layout including the annotation columns
+		width := len(report.ColumnNames(r.Columns)) + len(r.AnnotationColumns)
+		if len(all.Values) > 0 && len(all.Values[0]) > width {
+			width = len(all.Values[0])
+		}
+		// Formats are cleared as well, so that no color or strikethrough is left on the rows after the new data
+		_, err = Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+			return r.Service.Spreadsheets.
+				BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+					Requests: []*sheets.Request{
+						{
+							UpdateCells: &sheets.UpdateCellsRequest{
+								Range: &sheets.GridRange{
+									SheetId:          sheetId,
+									StartColumnIndex: 0,
+									EndColumnIndex:   int64(width),
+								},
+								Fields: "userEnteredValue,userEnteredFormat",
+							},
+						},
+					},
+				}).
+				Do()
+		})
+		if err != nil {
+			return err
+		}
+		// The header is written even without findings, so that the sheet is still known by its header
+		if len(findings) > 0 {
+			err = r.colorSheet(sheetId, findings)
+			if err != nil {
+				return err
+			}
+		}
+		err = r.updateSheet(title, findings)
+		if err != nil {
+			return err
+		}
+		err = r.updateAnnotationHeader(title)
+		if err != nil {
+			return err
+		}
+		return r.restoreAnnotations(title, findings, annotations)
+	}
+
+	// Annotation columns may have been added to the config since the last run
+	err = r.updateAnnotationHeader(title)
+	if err != nil {
+		return err
+	}
+
+	links := make([][]string, 0)
+	for _, f := range findings {
+		links = append(links, report.CreateRowLinks(r.Columns, f))
+	}
+	diff := r.diffRows(title, res.Values, newValues, links, annotations)
+	log.Printf("  %d added, %d updated, %d resolved", diff.added, diff.updated, diff.resolved)
+
+	if len(diff.updates) > 0 {
+		_, err = Retry(func() (*sheets.BatchUpdateValuesResponse, error) {
+			return r.Service.Spreadsheets.Values.
+				BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateValuesRequest{
+					Data:             diff.updates,
+					ValueInputOption: "RAW",
+				}).
+				Do()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// Annotations are written as they were entered, such as formulas
+	if len(diff.annotationUpdates) > 0 {
+		_, err = Retry(func() (*sheets.BatchUpdateValuesResponse, error) {
+			return r.Service.Spreadsheets.Values.
+				BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateValuesRequest{
+					Data:             diff.annotationUpdates,
+					ValueInputOption: "USER_ENTERED",
+				}).
+				Do()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete the rows from the bottom so that the indices of the remaining rows are not shifted
+	requests := make([]*sheets.Request, 0)
+	for i := len(diff.deletedRows) - 1; i >= 0; i-- {
+		requests = append(requests, &sheets.Request{
+			DeleteDimension: &sheets.DeleteDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    sheetId,
+					Dimension:  "ROWS",
+					StartIndex: diff.deletedRows[i],
+					EndIndex:   diff.deletedRows[i] + 1,
+				},
+			},
+		})
+	}
+	requests = append(requests, r.getSyncFormatRequests(sheetId, diff.rows)...)
+	if len(requests) == 0 {
+		return nil
+	}
+
+	_, err = Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+		return r.Service.Spreadsheets.
+			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// diffRows compares the existing rows of the sheet including the header with the new rows of the findings,
+// and returns the changes. The new rows are also with the header, and the links are by new data row.
+// The appended rows get the annotations of the findings read from all the project sheets.
+func (r SecurityHubSpreadSheet) diffRows(title string, existing [][]interface{}, newValues [][]interface{}, links [][]string, annotations Annotations) syncDiff {
+	idColumn := report.ColumnIndex(r.Columns, "ID")
+	resourceColumn := report.ColumnIndex(r.Columns, "Resource")
+
+	key2Values := make(map[string][]interface{}, 0)
+	key2Links := make(map[string][]string, 0)
+	for i, v := range newValues[1:] {
+		key := r.rowKey(v, idColumn, resourceColumn)
+		key2Values[key] = v
+		key2Links[key] = links[i]
+	}
+
+	// Diff the existing rows with the findings
+	ret := syncDiff{}
+	existingKeys := make(map[string]bool, 0)
+	for i, row := range existing[1:] {
+		rowIndex := int64(i + 1)
+		if cell(row, idColumn) == "" {
+			ret.rows = append(ret.rows, syncRow{values: row, blank: true})
+			continue
+		}
+		key := r.rowKey(row, idColumn, resourceColumn)
+		existingKeys[key] = true
+		v, ok := key2Values[key]
+		if !ok {
+			if r.ResolvedRowAction == cfg.ResolvedRowActionMark {
+				// Clear the exported workflow status, so that the import skips the resolved row
+				if c := report.ColumnIndex(r.Columns, ExportedWorkflowStatusColumn); cell(row, c) != "" {
+					ret.updates = append(ret.updates, &sheets.ValueRange{
+						Range:  a1Range(title, fmt.Sprintf("%s%d", columnLetter(c), rowIndex+1)),
+						Values: [][]interface{}{{""}},
+					})
+				}
+				ret.rows = append(ret.rows, syncRow{values: row, resolved: true})
+			} else {
+				ret.deletedRows = append(ret.deletedRows, rowIndex)
+			}
+			ret.resolved++
+			continue
+		}
+		if !equalRow(row, v) {
+			ret.updates = append(ret.updates, &sheets.ValueRange{
+				Range:  a1Range(title, fmt.Sprintf("A%d", rowIndex+1)),
+				Values: [][]interface{}{v},
+			})
+			ret.updated++
+		}
+		ret.rows = append(ret.rows, syncRow{values: v, links: key2Links[key]})
+	}
+
+	// Append the new findings after the last existing row
+	added := make([][]interface{}, 0)
+	addedAnnotations := make([][]interface{}, 0)
+	annotated := false
+	for _, v := range newValues[1:] {
+		key := r.rowKey(v, idColumn, resourceColumn)
+		if !existingKeys[key] {
+			added = append(added, v)
+			addedAnnotations = append(addedAnnotations, r.annotationRow(annotations, key))
+			annotated = annotated || len(annotations[key]) > 0
+			ret.rows = append(ret.rows, syncRow{values: v, links: key2Links[key]})
+		}
+	}
+	if len(added) > 0 {
+		ret.updates = append(ret.updates, &sheets.ValueRange{
+			Range:  a1Range(title, fmt.Sprintf("A%d", len(existing)+1)),
+			Values: added,
+		})
+	}
+	if annotated && len(r.AnnotationColumns) > 0 {
+		ret.annotationUpdates = append(ret.annotationUpdates, &sheets.ValueRange{
+			Range:  a1Range(title, fmt.Sprintf("%s%d", columnLetter(len(report.ColumnNames(r.Columns))), len(existing)+1)),
+			Values: addedAnnotations,
+		})
+	}
+	ret.added = len(added)
+	return ret
+}
+
+// getSyncFormatRequests returns the requests to color the rows by severity, strike through the resolved rows,
+// and create the links of the active rows. Blank rows are left as they are.
+func (r SecurityHubSpreadSheet) getSyncFormatRequests(sheetId int64, rows []syncRow) []*sheets.Request {
+	severityColumn := report.ColumnIndex(r.Columns, "Severity")
+
+	requests := make([]*sheets.Request, 0)
+
+	// Format the consecutive rows with the same severity and state at once
+	start := 0
+	for i := 1; i <= len(rows); i++ {
+		if i < len(rows) &&
+			cell(rows[i].values, severityColumn) == cell(rows[start].values, severityColumn) &&
+			rows[i].resolved == rows[start].resolved &&
+			rows[i].blank == rows[start].blank {
+			continue
+		}
+		if rows[start].blank {
+			start = i
+			continue
+		}
+		format := &sheets.CellFormat{
+			TextFormat: &sheets.TextFormat{
+				Strikethrough: rows[start].resolved,
+			},
+		}
+		if c, ok := r.Colors[strings.ToUpper(cell(rows[start].values, severityColumn))]; ok && !rows[start].resolved {
+			format.BackgroundColor = &c
+		}
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Cell: &sheets.CellData{
+					UserEnteredFormat: format,
+				},
+				Range: &sheets.GridRange{
+					SheetId:          sheetId,
+					StartRowIndex:    int64(start + 1),
+					EndRowIndex:      int64(i + 1),
+					StartColumnIndex: 0,
+					EndColumnIndex:   int64(len(report.ColumnNames(r.Columns))),
+				},
+				Fields: "userEnteredFormat.backgroundColor,userEnteredFormat.textFormat.strikethrough",
+			},
+		})
+		start = i
+	}
+
+	return append(requests, linkRequests(sheetId, r.Columns, rows)...)
+}
+
+// rowKey returns the key identifying the row in a project sheet, which includes the resource
+// if a finding is copied for each resource
+func (r SecurityHubSpreadSheet) rowKey(row []interface{}, idColumn int, resourceColumn int) string {
+	if r.MultiResourcePolicy == group.MultiResourcePolicyResource {
+		return cell(row, idColumn) + "\n" + cell(row, resourceColumn)
+	}
+	return cell(row, idColumn)
+}
+
+// findingKey returns the key of the row of the finding in the same way as rowKey
+func (r SecurityHubSpreadSheet) findingKey(f shTypes.AwsSecurityFinding) string {
+	if r.MultiResourcePolicy == group.MultiResourcePolicyResource {
+		return *f.Id + "\n" + report.ResourceIds(f)
+	}
+	return *f.Id
+}
+
+func cell(row []interface{}, column int) string {
+	if column < 0 || column >= len(row) {
+		return ""
+	}
+	return fmt.Sprint(row[column])
+}
+
+func equalRow(a []interface{}, b []interface{}) bool {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if cell(a, i) != cell(b, i) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package sheet
+
+import (
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/kota65535/securityhub-exporter/group"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
+	"google.golang.org/api/sheets/v4"
+	"testing"
+)
+
+var header = report.ColumnNames(report.DefaultColumns())
+
+func newRow(id string, severity string, title string) []interface{} {
+	return []interface{}{id, severity, title, "arn:aws:s3:::bucket", "NEW", "Security Hub", "ap-northeast-1", "111111111111", "2023-08-01", "2023-08-02"}
+}
+
+func TestRowKey(t *testing.T) {
+	row := []interface{}{"a", "HIGH", "title", "arn:aws:s3:::bucket"}
+
+	r := SecurityHubSpreadSheet{MultiResourcePolicy: group.MultiResourcePolicyFinding}
+	assert.Equal(t, "a", r.rowKey(row, 0, 3))
+
+	r = SecurityHubSpreadSheet{MultiResourcePolicy: group.MultiResourcePolicyResource}
+	assert.Equal(t, "a\narn:aws:s3:::bucket", r.rowKey(row, 0, 3))
+	assert.Equal(t, "a\n", r.rowKey(row, 0, -1))
+}
+
+func TestParseAnnotations(t *testing.T) {
+	r := SecurityHubSpreadSheet{
+		Columns:             report.DefaultColumns(),
+		AnnotationColumns:   []string{"Owner"},
+		MultiResourcePolicy: group.MultiResourcePolicyFinding,
+	}
+
+	annotations := make(Annotations, 0)
+	values := [][]interface{}{
+		append(slices.Clone(header), "Owner"),
+		append(newRow("a", "HIGH", "title"), "alice"),
+		append(newRow("", "HIGH", "title"), "bob"),
+	}
+	assert.NoError(t, r.parseAnnotations(values, annotations))
+	assert.Equal(t, Annotations{"a": {"Owner": "alice"}}, annotations)
+
+	// Sheets without annotation columns have nothing to lose
+	annotations = make(Annotations, 0)
+	assert.NoError(t, r.parseAnnotations([][]interface{}{{"Title"}, {"title"}}, annotations))
+	assert.Empty(t, annotations)
+
+	// Annotations cannot be keyed without the ID column
+	assert.Error(t, r.parseAnnotations([][]interface{}{{"Title", "Owner"}, {"title", "alice"}}, annotations))
+
+	r.MultiResourcePolicy = group.MultiResourcePolicyResource
+	assert.Error(t, r.parseAnnotations([][]interface{}{{"ID", "Owner"}, {"a", "alice"}}, annotations))
+}
+
+func TestEqualRow(t *testing.T) {
+	assert.True(t, equalRow([]interface{}{"a", 1}, []interface{}{"a", "1"}))
+	// Trailing empty cells are omitted by the API
+	assert.True(t, equalRow([]interface{}{"a"}, []interface{}{"a", ""}))
+	assert.False(t, equalRow([]interface{}{"a", "b"}, []interface{}{"a"}))
+	assert.False(t, equalRow([]interface{}{"a", "b"}, []interface{}{"a", "c"}))
+}
+
+func TestDiffRows(t *testing.T) {
+	existing := [][]interface{}{
+		header,
+		newRow("a", "HIGH", "a"),
+		newRow("b", "HIGH", "old title"),
+		{"", "", "added by user"},
+		newRow("c", "LOW", "c"),
+	}
+	newValues := [][]interface{}{
+		header,
+		newRow("a", "HIGH", "a"),
+		newRow("b", "HIGH", "new title"),
+		newRow("d", "CRITICAL", "d"),
+	}
+	links := [][]string{{"link a"}, {"link b"}, {"link d"}}
+
+	r := SecurityHubSpreadSheet{Columns: report.DefaultColumns(), ResolvedRowAction: cfg.ResolvedRowActionDelete}
+	diff := r.diffRows("foo", existing, newValues, links, nil)
+	assert.Equal(t, 1, diff.added)
+	assert.Equal(t, 1, diff.updated)
+	assert.Equal(t, 1, diff.resolved)
+	assert.Equal(t, []int64{4}, diff.deletedRows)
+	assert.Len(t, diff.updates, 2)
+	assert.Equal(t, "'foo'!A3", diff.updates[0].Range)
+	assert.Equal(t, "'foo'!A6", diff.updates[1].Range)
+	assert.Equal(t, [][]interface{}{newRow("d", "CRITICAL", "d")}, diff.updates[1].Values)
+	// The blank row is kept in place
+	assert.Len(t, diff.rows, 4)
+	assert.True(t, diff.rows[2].blank)
+	assert.Equal(t, []string{"link d"}, diff.rows[3].links)
+
+	r.ResolvedRowAction = cfg.ResolvedRowActionMark
+	diff = r.diffRows("foo", existing, newValues, links, nil)
+	assert.Empty(t, diff.deletedRows)
+	assert.Equal(t, 1, diff.resolved)
+	assert.Len(t, diff.rows, 5)
+	assert.True(t, diff.rows[3].resolved)
+	assert.Equal(t, "c", diff.rows[3].values[0])
+	assert.Empty(t, diff.annotationUpdates)
+
+	// Annotations of the finding moved from another sheet are written to the appended row
+	r.AnnotationColumns = []string{"Owner", "Ticket"}
+	diff = r.diffRows("foo", existing, newValues, links, Annotations{"d": {"Ticket": "SEC-1"}})
+	assert.Len(t, diff.annotationUpdates, 1)
+	assert.Equal(t, "'foo'!K6", diff.annotationUpdates[0].Range)
+	assert.Equal(t, [][]interface{}{{"", "SEC-1"}}, diff.annotationUpdates[0].Values)
+}
+
+func TestGetSyncFormatRequests(t *testing.T) {
+	high := sheets.Color{Red: 1}
+	r := SecurityHubSpreadSheet{Columns: report.DefaultColumns(), Colors: map[string]sheets.Color{"HIGH": high}}
+	rows := []syncRow{
+		{values: newRow("a", "HIGH", "a"), links: []string{"link a"}},
+		{values: newRow("b", "HIGH", "b"), links: []string{"link b"}},
+		{values: []interface{}{"", "", "added by user"}, blank: true},
+		{values: newRow("c", "HIGH", "c"), resolved: true},
+	}
+	requests := r.getSyncFormatRequests(1, rows)
+
+	// Two active rows, the resolved row and the links of the active rows
+	assert.Len(t, requests, 3)
+	active := requests[0].RepeatCell
+	assert.Equal(t, int64(1), active.Range.StartRowIndex)
+	assert.Equal(t, int64(3), active.Range.EndRowIndex)
+	assert.Equal(t, &high, active.Cell.UserEnteredFormat.BackgroundColor)
+	assert.False(t, active.Cell.UserEnteredFormat.TextFormat.Strikethrough)
+	resolved := requests[1].RepeatCell
+	assert.Equal(t, int64(4), resolved.Range.StartRowIndex)
+	assert.Equal(t, int64(5), resolved.Range.EndRowIndex)
+	assert.Nil(t, resolved.Cell.UserEnteredFormat.BackgroundColor)
+	assert.True(t, resolved.Cell.UserEnteredFormat.TextFormat.Strikethrough)
+	assert.NotNil(t, requests[2].UpdateCells)
+}
+
+func TestSyncSheetsMovedToNewProject(t *testing.T) {
+	columns := report.DefaultColumns()[:3]
+	header := append(report.ColumnNames(columns), "Owner")
+	foo := &sheets.Sheet{
+		Properties:        &sheets.SheetProperties{SheetId: 1, Title: "foo"},
+		DeveloperMetadata: []*sheets.DeveloperMetadata{{MetadataKey: ProjectSheetMetadataKey}},
+	}
+	r, fake := newFakeSpreadSheet(t, []*sheets.Sheet{foo}, map[string][][]interface{}{
+		"foo": {header, {"a", "HIGH", "title", "alice"}},
+	})
+	r.Columns = columns
+	r.AnnotationColumns = []string{"Owner"}
+	r.MultiResourcePolicy = group.MultiResourcePolicyFinding
+	r.ResolvedRowAction = cfg.ResolvedRowActionDelete
+
+	id, region, title := "a", "ap-northeast-1", "title"
+	finding := shTypes.AwsSecurityFinding{Id: &id, Region: &region, Title: &title, Severity: &shTypes.Severity{Label: shTypes.SeverityLabelHigh}}
+	err := r.SyncSheets(map[string][]shTypes.AwsSecurityFinding{"bar": {finding}})
+	assert.NoError(t, err)
+
+	// The annotations of the finding are moved with it to the created sheet
+	assert.Equal(t, [][]interface{}{{"alice"}}, fake.written["'bar'!D2"])
+}
+
+func TestSyncSheetsRewriteWithoutFindings(t *testing.T) {
+	columns := report.DefaultColumns()[:3]
+	foo := &sheets.Sheet{
+		Properties:        &sheets.SheetProperties{SheetId: 1, Title: "foo"},
+		DeveloperMetadata: []*sheets.DeveloperMetadata{{MetadataKey: ProjectSheetMetadataKey}},
+	}
+	// Sheet of the previous layout
+	r, fake := newFakeSpreadSheet(t, []*sheets.Sheet{foo}, map[string][][]interface{}{
+		"foo": {{"ID", "Title", "Owner"}, {"a", "title", "alice"}},
+	})
+	r.Columns = columns
+	r.AnnotationColumns = []string{"Owner"}
+	r.MultiResourcePolicy = group.MultiResourcePolicyFinding
+	r.ResolvedRowAction = cfg.ResolvedRowActionMark
+
+	err := r.SyncSheets(nil)
+	assert.NoError(t, err)
+
+	// The sheet is left with the header to be known by it in the next run
+	assert.Equal(t, [][]interface{}{report.ColumnNames(columns)}, fake.written["'foo'!A1"])
+	assert.Equal(t, [][]interface{}{{"Owner"}}, fake.written["'foo'!D1"])
+}
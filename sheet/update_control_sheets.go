@@ -0,0 +1,177 @@
+package sheet
+
+import (
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/report"
+	"google.golang.org/api/sheets/v4"
+	"log"
+)
+
+// Prefix of the titles of the control sheets
+const ControlSheetPrefix = "Controls: "
+
+// ControlSheetMetadataKey is the key of the developer metadata marking the control sheets created by the exporter
+const ControlSheetMetadataKey = "securityhub-exporter.controls"
+
+// UpdateControlSheets writes a sheet for each security standard, with the pass/fail counts of its controls by project.
+// The sheets are updated in place, and those of the standards no longer found are deleted.
+// Only the sheets with the metadata are deleted, so that the sheets added by users are left untouched.
+func (r SecurityHubSpreadSheet) UpdateControlSheets(project2Findings map[string][]shTypes.AwsSecurityFinding) error {
+	sheetz, err := r.GetAllSheets(nil)
+	if err != nil {
+		return err
+	}
+	title2SheetId := make(map[string]int64, 0)
+	title2Sheet := make(map[string]*sheets.Sheet, 0)
+	for _, s := range sheetz {
+		title2SheetId[s.Properties.Title] = s.Properties.SheetId
+		title2Sheet[s.Properties.Title] = s
+	}
+
+	matrices := report.CreateControlMatrices(project2Findings, r.ControlStandards)
+	titles := make(map[string]bool, 0)
+	for _, m := range matrices {
+		title := ControlSheetPrefix + m.Name()
+		titles[title] = true
+		log.Printf("Updating control sheet for '%s'...", m.Name())
+
+		var sheetId int64
+		s, ok := title2Sheet[title]
+		switch {
+		case !ok:
+			sheetId, err = r.createSheet(title)
+			if err != nil {
+				return err
+			}
+			err = r.markSheets([]int64{sheetId}, ControlSheetMetadataKey)
+		case !hasSheetMetadata(s, ControlSheetMetadataKey):
+			// Created before the sheets were marked
+			sheetId = s.Properties.SheetId
+			err = r.markSheets([]int64{sheetId}, ControlSheetMetadataKey)
+		default:
+			sheetId = s.Properties.SheetId
+		}
+		if err != nil {
+			return err
+		}
+		err = r.updateControlSheet(sheetId, m, title2SheetId)
+		if err != nil {
+			return err
+		}
+	}
+
+	requests := make([]*sheets.Request, 0)
+	for _, s := range sheetz {
+		if hasSheetMetadata(s, ControlSheetMetadataKey) && !titles[s.Properties.Title] {
+			log.Printf("Deleting control sheet '%s'...", s.Properties.Title)
+			requests = append(requests, &sheets.Request{DeleteSheet: &sheets.DeleteSheetRequest{
+				SheetId: s.Properties.SheetId,
+			}})
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	_, err = Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+		return r.Service.Spreadsheets.
+			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateControlSheet rewrites the control sheet with a row for each control and a column for each project,
+// whose cells are the failed and passed counts linked to the project sheet
+func (r SecurityHubSpreadSheet) updateControlSheet(sheetId int64, m report.ControlMatrix, title2SheetId map[string]int64) error {
+	bold := &sheets.CellFormat{TextFormat: &sheets.TextFormat{Bold: true}}
+	header := []*sheets.CellData{
+		stringCell("Control", bold),
+		stringCell("Title", bold),
+		stringCell("Failed", bold),
+		stringCell("Passed", bold),
+	}
+	for _, project := range m.Projects {
+		header = append(header, stringCell(project, projectLinkFormat(project, title2SheetId, true)))
+	}
+	rows := []*sheets.RowData{{Values: header}}
+
+	for _, c := range m.Controls {
+		values := []*sheets.CellData{
+			stringCell(c.Id, nil),
+			stringCell(c.Title, nil),
+			numberCell(c.Total.Failed),
+			numberCell(c.Total.Passed),
+		}
+		for _, project := range m.Projects {
+			count, ok := c.Project2Count[project]
+			if !ok {
+				values = append(values, &sheets.CellData{})
+				continue
+			}
+			values = append(values, stringCell(fmt.Sprintf("%d / %d", count.Failed, count.Passed), projectLinkFormat(project, title2SheetId, false)))
+		}
+		rows = append(rows, &sheets.RowData{Values: values})
+	}
+
+	requests := []*sheets.Request{
+		// Clear the values and the formats of the previous run
+		{
+			UpdateCells: &sheets.UpdateCellsRequest{
+				Range:  &sheets.GridRange{SheetId: sheetId},
+				Fields: "userEnteredValue,userEnteredFormat",
+			},
+		},
+		{
+			UpdateCells: &sheets.UpdateCellsRequest{
+				Rows: rows,
+				Start: &sheets.GridCoordinate{
+					SheetId:     sheetId,
+					RowIndex:    0,
+					ColumnIndex: 0,
+				},
+				Fields: "userEnteredValue,userEnteredFormat",
+			},
+		},
+		{
+			AutoResizeDimensions: &sheets.AutoResizeDimensionsRequest{
+				Dimensions: &sheets.DimensionRange{
+					SheetId:    sheetId,
+					Dimension:  "COLUMNS",
+					StartIndex: 0,
+					EndIndex:   int64(len(header)),
+				},
+			},
+		},
+	}
+	_, err := Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+		return r.Service.Spreadsheets.
+			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// projectLinkFormat returns the format of the cell, which is linked to the project sheet if it exists
+func projectLinkFormat(project string, title2SheetId map[string]int64, bold bool) *sheets.CellFormat {
+	format := &sheets.CellFormat{TextFormat: &sheets.TextFormat{Bold: bold}}
+	if sheetId, ok := title2SheetId[project]; ok {
+		format.TextFormat.Link = &sheets.Link{Uri: fmt.Sprintf("#gid=%d", sheetId)}
+	}
+	return format
+}
+
+func stringCell(value string, format *sheets.CellFormat) *sheets.CellData {
+	return &sheets.CellData{
+		UserEnteredValue:  &sheets.ExtendedValue{StringValue: &value},
+		UserEnteredFormat: format,
+	}
+}
@@ -0,0 +1,21 @@
+package sheet
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/sheets/v4"
+	"testing"
+)
+
+func TestUpdateControlSheetsDeletesMarkedOnly(t *testing.T) {
+	marked := &sheets.Sheet{
+		Properties:        &sheets.SheetProperties{SheetId: 1, Title: ControlSheetPrefix + "old-standard v1.0.0"},
+		DeveloperMetadata: []*sheets.DeveloperMetadata{{MetadataKey: ControlSheetMetadataKey}},
+	}
+	// Added by a user with the same prefix
+	unmarked := &sheets.Sheet{Properties: &sheets.SheetProperties{SheetId: 2, Title: ControlSheetPrefix + "notes"}}
+	r, fake := newFakeSpreadSheet(t, []*sheets.Sheet{marked, unmarked}, nil)
+
+	err := r.UpdateControlSheets(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*sheets.Sheet{unmarked}, fake.sheets)
+}
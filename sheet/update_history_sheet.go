@@ -0,0 +1,162 @@
+package sheet
+
+import (
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/report"
+	"google.golang.org/api/sheets/v4"
+	"log"
+	"time"
+)
+
+const (
+	// Column of the table of the top projects for the chart, next to the history rows
+	historyChartColumn = 9
+	historyChartTitle  = "Findings by project"
+)
+
+// UpdateHistorySheet appends the number of the findings by severity for each project to the history sheet,
+// and draws the trend of the top projects. The history rows are never cleared.
+func (r SecurityHubSpreadSheet) UpdateHistorySheet(project2Findings map[string][]shTypes.AwsSecurityFinding, insights []aws.Insight, fetchedAt time.Time) error {
+	s, err := r.getOrCreateHistorySheet()
+	if err != nil {
+		return err
+	}
+	title := s.Properties.Title
+
+	res, err := Retry(func() (*sheets.ValueRange, error) {
+		return r.Service.Spreadsheets.Values.
+			Get(r.Spreadsheet.SpreadsheetId, a1Range(title, "A2:"+columnLetter(len(report.HistoryHeader)-1))).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+	history := res.Values
+
+	projectFindings := projectsWithoutInsights(project2Findings, insights)
+
+	// Runs from the same snapshot are appended only once
+	date := fetchedAt.UTC().Format("2006-01-02 15:04")
+	appended := false
+	for _, row := range history {
+		if len(row) > 0 && fmt.Sprint(row[0]) == date {
+			appended = true
+			break
+		}
+	}
+	if appended {
+		log.Printf("  history at %s already exists, skip to append", date)
+	} else {
+		rows := report.CreateHistoryRows(date, projectFindings)
+		_, err = Retry(func() (*sheets.AppendValuesResponse, error) {
+			return r.Service.Spreadsheets.Values.
+				Append(r.Spreadsheet.SpreadsheetId, a1Range(title, "A1"), &sheets.ValueRange{Values: rows}).
+				ValueInputOption("RAW").
+				InsertDataOption("INSERT_ROWS").
+				Do()
+		})
+		if err != nil {
+			return err
+		}
+		history = append(history, rows...)
+	}
+
+	return r.updateHistoryChart(s, report.CreateHistoryChartValues(history, r.HistoryChartProjects))
+}
+
+func (r SecurityHubSpreadSheet) getOrCreateHistorySheet() (*sheets.Sheet, error) {
+	sheetz, err := r.GetAllSheets(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sheetz {
+		if s.Properties.Title == r.HistorySheetName {
+			return s, nil
+		}
+	}
+
+	log.Printf("Creating history sheet '%s'...", r.HistorySheetName)
+	_, err = r.createSheet(r.HistorySheetName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = Retry(func() (*sheets.UpdateValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			Update(r.Spreadsheet.SpreadsheetId, a1Range(r.HistorySheetName, "A1"), &sheets.ValueRange{Values: [][]interface{}{report.HistoryHeader}}).
+			ValueInputOption("RAW").
+			Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetSheet(r.HistorySheetName)
+}
+
+// updateHistoryChart rewrites the table of the top projects, and adds or updates the line chart of the table
+func (r SecurityHubSpreadSheet) updateHistoryChart(s *sheets.Sheet, values [][]interface{}) error {
+	title := s.Properties.Title
+	sheetId := s.Properties.SheetId
+	tableRange := columnLetter(historyChartColumn) + ":" + columnLetter(historyChartColumn+report.MaxHistoryChartProjects)
+
+	_, err := Retry(func() (*sheets.ClearValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			Clear(r.Spreadsheet.SpreadsheetId, a1Range(title, tableRange), &sheets.ClearValuesRequest{}).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	_, err = Retry(func() (*sheets.UpdateValuesResponse, error) {
+		return r.Service.Spreadsheets.Values.
+			Update(r.Spreadsheet.SpreadsheetId, a1Range(title, columnLetter(historyChartColumn)+"1"), &sheets.ValueRange{Values: values}).
+			ValueInputOption("RAW").
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+
+	endRow := int64(len(values))
+	series := make([]*sheets.BasicChartSeries, 0)
+	for i := 1; i < len(values[0]); i++ {
+		series = append(series, &sheets.BasicChartSeries{
+			Series:     chartData(sheetId, 0, endRow, int64(historyChartColumn+i)),
+			TargetAxis: "LEFT_AXIS",
+		})
+	}
+	spec := &sheets.ChartSpec{
+		Title: historyChartTitle,
+		BasicChart: &sheets.BasicChartSpec{
+			ChartType:      "LINE",
+			LegendPosition: "RIGHT_LEGEND",
+			HeaderCount:    1,
+			Axis: []*sheets.BasicChartAxis{
+				{Position: "BOTTOM_AXIS", Title: "Date"},
+				{Position: "LEFT_AXIS", Title: "Findings"},
+			},
+			Domains: []*sheets.BasicChartDomain{
+				{Domain: chartData(sheetId, 0, endRow, historyChartColumn)},
+			},
+			Series: series,
+		},
+	}
+	position := overlayPosition(sheetId, 0, historyChartColumn+report.MaxHistoryChartProjects+2)
+
+	_, err = Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
+		return r.Service.Spreadsheets.
+			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+				Requests: []*sheets.Request{chartRequest(s, spec, position)},
+			}).
+			Do()
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,18 +3,27 @@ package sheet
 import (
 	"fmt"
 	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
 	"google.golang.org/api/sheets/v4"
 	"strconv"
 )
 
-func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]shTypes.AwsSecurityFinding) error {
+// UpdateIndexSheet writes the link and the number of findings for each project sheet, followed by the results of the insights,
+// and the charts of the number of findings by severity
+func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]shTypes.AwsSecurityFinding, insights []aws.Insight) error {
 	sheetz, err := r.GetAllSheets(nil)
 	if err != nil {
 		return err
 	}
+	projectSheets, err := r.GetProjectSheets()
+	if err != nil {
+		return err
+	}
 
 	// Clear sheet
-	allRange := fmt.Sprintf("%s!A2:Z", r.IndexSheetName)
+	allRange := a1Range(r.IndexSheetName, "A2:Z")
 	_, err = Retry(func() (*sheets.ClearValuesResponse, error) {
 		return r.Service.Spreadsheets.Values.
 			Clear(r.Spreadsheet.SpreadsheetId, allRange, &sheets.ClearValuesRequest{}).
@@ -24,12 +33,13 @@ func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]s
 		return err
 	}
 
+	// Sheets of the insights follow the others out of the charts
+	projects := projectsWithoutInsights(project2Findings, insights)
+
 	// Create links for each sheet
 	rows := make([]*sheets.RowData, 0)
-	for _, s := range sheetz {
-		if s.Properties.Title == r.IndexSheetName {
-			continue
-		}
+	insightSheetRows := make([]*sheets.RowData, 0)
+	for _, s := range projectSheets {
 		uri := fmt.Sprintf("#gid=%d", s.Properties.SheetId)
 		values := []*sheets.CellData{
 			{
@@ -45,29 +55,24 @@ func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]s
 				},
 			},
 		}
+		// Numbers so that the charts can plot them
 		findings := project2Findings[s.Properties.Title]
-		lenStr := strconv.Itoa(len(findings))
-		values = append(values, &sheets.CellData{
-			UserEnteredValue: &sheets.ExtendedValue{
-				StringValue: &lenStr,
-			},
-		})
+		values = append(values, numberCell(len(findings)))
 		for _, severity := range r.Severities {
-			count := 0
-			for _, f := range findings {
-				if (string)(f.Severity.Label) == (string)(severity) {
-					count++
-				}
-			}
-			countStr := strconv.Itoa(count)
-			values = append(values, &sheets.CellData{
-				UserEnteredValue: &sheets.ExtendedValue{
-					StringValue: &countStr,
-				},
-			})
+			values = append(values, numberCell(report.CountBySeverity(findings, severity)))
+		}
+		// Projects without findings are not in either, but their sheets may be kept in sync mode
+		_, isProject := projects[s.Properties.Title]
+		if _, ok := project2Findings[s.Properties.Title]; ok && !isProject {
+			insightSheetRows = append(insightSheetRows, &sheets.RowData{Values: values})
+		} else {
+			rows = append(rows, &sheets.RowData{Values: values})
 		}
-		rows = append(rows, &sheets.RowData{Values: values})
 	}
+	projectRows := len(rows)
+	rows = append(rows, insightSheetRows...)
+
+	rows = append(rows, createInsightRows(sheetz, insights)...)
 
 	requests := []*sheets.Request{
 		{
@@ -76,7 +81,7 @@ func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]s
 				Range: &sheets.GridRange{
 					SheetId:          0,
 					StartRowIndex:    1,
-					EndRowIndex:      int64(len(sheetz) + 1),
+					EndRowIndex:      int64(len(rows) + 1),
 					StartColumnIndex: 0,
 					EndColumnIndex:   0,
 				},
@@ -94,6 +99,11 @@ func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]s
 			},
 		},
 	}
+	for _, s := range sheetz {
+		if s.Properties.Title == r.IndexSheetName {
+			requests = append(requests, r.getIndexChartRequests(s, projectRows, project2Findings, insights)...)
+		}
+	}
 	_, err = Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
 		return r.Service.Spreadsheets.
 			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
@@ -105,3 +115,56 @@ func (r SecurityHubSpreadSheet) UpdateIndexSheet(project2Findings map[string][]s
 
 	return nil
 }
+
+// createInsightRows returns the section of the results of each insight grouped by its attribute
+func createInsightRows(sheetz []*sheets.Sheet, insights []aws.Insight) []*sheets.RowData {
+	title2SheetId := make(map[string]int64, 0)
+	for _, s := range sheetz {
+		title2SheetId[s.Properties.Title] = s.Properties.SheetId
+	}
+
+	rows := make([]*sheets.RowData, 0)
+	for _, i := range insights {
+		// Empty row as a separator
+		rows = append(rows, &sheets.RowData{})
+
+		name := i.Name
+		title := &sheets.CellData{
+			UserEnteredValue: &sheets.ExtendedValue{StringValue: &name},
+			UserEnteredFormat: &sheets.CellFormat{
+				TextFormat: &sheets.TextFormat{Bold: true},
+			},
+		}
+		if sheetId, ok := title2SheetId[exporter.InsightProjectName(i.Name)]; ok {
+			title.UserEnteredFormat.TextFormat.Link = &sheets.Link{Uri: fmt.Sprintf("#gid=%d", sheetId)}
+		}
+		groupBy := i.GroupByAttribute
+		count := "Count"
+		rows = append(rows, &sheets.RowData{
+			Values: []*sheets.CellData{
+				title,
+				{
+					UserEnteredValue:  &sheets.ExtendedValue{StringValue: &groupBy},
+					UserEnteredFormat: &sheets.CellFormat{TextFormat: &sheets.TextFormat{Bold: true}},
+				},
+				{
+					UserEnteredValue:  &sheets.ExtendedValue{StringValue: &count},
+					UserEnteredFormat: &sheets.CellFormat{TextFormat: &sheets.TextFormat{Bold: true}},
+				},
+			},
+		})
+
+		for _, result := range i.Results {
+			value := result.Value
+			countStr := strconv.Itoa(result.Count)
+			rows = append(rows, &sheets.RowData{
+				Values: []*sheets.CellData{
+					{},
+					{UserEnteredValue: &sheets.ExtendedValue{StringValue: &value}},
+					{UserEnteredValue: &sheets.ExtendedValue{StringValue: &countStr}},
+				},
+			})
+		}
+	}
+	return rows
+}
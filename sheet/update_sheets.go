@@ -1,47 +1,27 @@
 package sheet
 
 import (
-	"fmt"
 	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
 	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/report"
+	"golang.org/x/exp/slices"
 	"google.golang.org/api/sheets/v4"
 	"log"
-	"net/url"
-	"sort"
-	"strings"
-	"time"
 )
 
-const (
-	base   = "https://%[1]s.console.aws.amazon.com/securityhub/home?region=%[1]s#/findings?search=Id%%3D"
-	prefix = `\operator\:EQUALS\:`
-)
-
-func (r SecurityHubSpreadSheet) UpdateSheets(project2Findings map[string][]shTypes.AwsSecurityFinding) error {
-	projects := make([]string, 0)
-	for p := range project2Findings {
-		projects = append(projects, p)
-	}
-	sort.Slice(projects, func(i, j int) bool {
-		return strings.ToLower(projects[i]) < strings.ToLower(projects[j])
-	})
+// UpdateSheets creates the project sheets, and restores the annotations read before the sheets were deleted
+func (r SecurityHubSpreadSheet) UpdateSheets(project2Findings map[string][]shTypes.AwsSecurityFinding, annotations Annotations) error {
+	projects := report.SortedProjects(project2Findings)
 
 	for _, project := range projects {
 		log.Printf("Updating sheets for '%s'...", project)
 		findings := project2Findings[project]
-		sheetId, err := r.createSheet(project)
-		if err != nil {
-			return err
-		}
-
-		sortFindings(findings)
-
-		err = r.colorSheet(sheetId, findings)
+		err := r.createProjectSheet(project, findings)
 		if err != nil {
 			return err
 		}
 
-		err = r.updateSheet(project, findings)
+		err = r.restoreAnnotations(project, findings, annotations)
 		if err != nil {
 			return err
 		}
@@ -50,6 +30,31 @@ func (r SecurityHubSpreadSheet) UpdateSheets(project2Findings map[string][]shTyp
 	return nil
 }
 
+func (r SecurityHubSpreadSheet) createProjectSheet(project string, findings []shTypes.AwsSecurityFinding) error {
+	sheetId, err := r.createSheet(project)
+	if err != nil {
+		return err
+	}
+	err = r.markProjectSheets([]int64{sheetId})
+	if err != nil {
+		return err
+	}
+
+	report.SortFindings(findings)
+
+	err = r.colorSheet(sheetId, findings)
+	if err != nil {
+		return err
+	}
+
+	err = r.updateSheet(project, findings)
+	if err != nil {
+		return err
+	}
+
+	return r.updateAnnotationHeader(project)
+}
+
 func (r SecurityHubSpreadSheet) createSheet(title string) (int64, error) {
 	requests := []*sheets.Request{
 		{
@@ -78,22 +83,6 @@ func (r SecurityHubSpreadSheet) createSheet(title string) (int64, error) {
 	return createdSheetID, nil
 }
 
-func sortFindings(findings []shTypes.AwsSecurityFinding) {
-	sort.Slice(findings, func(i, j int) bool {
-		// Descending order of severity
-		if findings[i].Severity.Label != findings[j].Severity.Label {
-			return findings[i].Severity.Normalized > findings[j].Severity.Normalized
-		}
-		// Descending order of updated time
-		t1, err1 := time.Parse(time.RFC3339, *findings[i].UpdatedAt)
-		t2, err2 := time.Parse(time.RFC3339, *findings[j].UpdatedAt)
-		if err1 != nil || err2 != nil {
-			return true
-		}
-		return t1.After(t2)
-	})
-}
-
 func (r SecurityHubSpreadSheet) colorSheet(sheetId int64, findings []shTypes.AwsSecurityFinding) error {
 	ranges := make(map[aws.Severity][]int)
 	for _, s := range aws.OrderedSeverities {
@@ -132,7 +121,7 @@ func (r SecurityHubSpreadSheet) colorSheet(sheetId int64, findings []shTypes.Aws
 					StartRowIndex:    int64(rng[0] + 1),
 					EndRowIndex:      int64(rng[1] + 1),
 					StartColumnIndex: 0,
-					EndColumnIndex:   int64(len(columnNames)),
+					EndColumnIndex:   int64(len(report.ColumnNames(r.Columns))),
 				},
 				Fields: "*",
 			},
@@ -154,8 +143,8 @@ func (r SecurityHubSpreadSheet) colorSheet(sheetId int64, findings []shTypes.Aws
 
 func (r SecurityHubSpreadSheet) updateSheet(project string, findings []shTypes.AwsSecurityFinding) error {
 	// Update sheet values
-	values := createRowValues(findings)
-	writeRange := project + "!A1"
+	values := report.CreateRowValues(r.Columns, findings)
+	writeRange := a1Range(project, "A1")
 	valueRange := &sheets.ValueRange{
 		Values: values,
 	}
@@ -175,23 +164,10 @@ func (r SecurityHubSpreadSheet) updateSheet(project string, findings []shTypes.A
 	}
 	sheetId := s.Properties.SheetId
 
-	// Create the link for each finding
-	rows := make([]*sheets.RowData, 0)
+	// Create the links of the cells for each finding
+	rows := make([]syncRow, 0)
 	for _, f := range findings {
-		uri := createUriToFinding(*f.Id, *f.Region)
-		rows = append(rows, &sheets.RowData{
-			Values: []*sheets.CellData{
-				{
-					UserEnteredFormat: &sheets.CellFormat{
-						TextFormat: &sheets.TextFormat{
-							Link: &sheets.Link{
-								Uri: uri,
-							},
-						},
-					},
-				},
-			},
-		})
+		rows = append(rows, syncRow{links: report.CreateRowLinks(r.Columns, f)})
 	}
 	requests := []*sheets.Request{
 		{
@@ -208,35 +184,29 @@ func (r SecurityHubSpreadSheet) updateSheet(project string, findings []shTypes.A
 					StartRowIndex:    0,
 					EndRowIndex:      1,
 					StartColumnIndex: 0,
-					EndColumnIndex:   int64(len(columnNames)),
+					EndColumnIndex:   int64(len(report.ColumnNames(r.Columns)) + len(r.AnnotationColumns)),
 				},
 				Fields: "userEnteredFormat.textFormat.bold",
 			},
 		},
-		{
-			UpdateCells: &sheets.UpdateCellsRequest{
-				Rows: rows,
-				Range: &sheets.GridRange{
-					SheetId:          sheetId,
-					StartRowIndex:    1,
-					EndRowIndex:      int64(len(findings) + 1),
-					StartColumnIndex: 0,
-					EndColumnIndex:   1,
-				},
-				Fields: "userEnteredFormat.textFormat.link",
-			},
-		},
-		{
+	}
+	requests = append(requests, linkRequests(sheetId, r.Columns, rows)...)
+	for i, c := range r.Columns {
+		if !c.AutoResize {
+			continue
+		}
+		requests = append(requests, &sheets.Request{
 			AutoResizeDimensions: &sheets.AutoResizeDimensionsRequest{
 				Dimensions: &sheets.DimensionRange{
-					SheetId:    s.Properties.SheetId,
+					SheetId:    sheetId,
 					Dimension:  "COLUMNS",
-					StartIndex: 2,
-					EndIndex:   4,
+					StartIndex: int64(i),
+					EndIndex:   int64(i + 1),
 				},
 			},
-		},
+		})
 	}
+	requests = append(requests, collapseRequests(sheetId, r.Columns, s.ColumnGroups)...)
 	_, err = Retry(func() (*sheets.BatchUpdateSpreadsheetResponse, error) {
 		return r.Service.Spreadsheets.
 			BatchUpdate(r.Spreadsheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
@@ -249,54 +219,95 @@ func (r SecurityHubSpreadSheet) updateSheet(project string, findings []shTypes.A
 	return nil
 }
 
-var columnNames = []interface{}{
-	"ID",
-	"Severity",
-	"Title",
-	"Resource",
-	"Workflow Status",
-	"Product Name",
-	"Region",
-	"Account ID",
-	"Created at",
-	"Updated at",
+// linkRequests returns the requests to set the links of the cells in the columns with links.
+// Resolved rows are skipped so that they keep the links set while their findings were active, and so are blank rows.
+func linkRequests(sheetId int64, columns []report.Column, rows []syncRow) []*sheets.Request {
+	requests := make([]*sheets.Request, 0)
+	for column, c := range columns {
+		if c.Link == nil {
+			continue
+		}
+		// Update the consecutive active rows at once
+		start := 0
+		for start < len(rows) {
+			if rows[start].resolved || rows[start].blank {
+				start++
+				continue
+			}
+			end := start
+			linkRows := make([]*sheets.RowData, 0)
+			for ; end < len(rows) && !rows[end].resolved && !rows[end].blank; end++ {
+				var link *sheets.Link
+				if uri := rows[end].links[column]; uri != "" {
+					link = &sheets.Link{Uri: uri}
+				}
+				linkRows = append(linkRows, &sheets.RowData{
+					Values: []*sheets.CellData{
+						{
+							UserEnteredFormat: &sheets.CellFormat{
+								TextFormat: &sheets.TextFormat{
+									Link: link,
+								},
+							},
+						},
+					},
+				})
+			}
+			requests = append(requests, &sheets.Request{
+				UpdateCells: &sheets.UpdateCellsRequest{
+					Rows: linkRows,
+					Range: &sheets.GridRange{
+						SheetId:          sheetId,
+						StartRowIndex:    int64(start + 1),
+						EndRowIndex:      int64(end + 1),
+						StartColumnIndex: int64(column),
+						EndColumnIndex:   int64(column + 1),
+					},
+					Fields: "userEnteredFormat.textFormat.link",
+				},
+			})
+			start = end
+		}
+	}
+	return requests
 }
 
-func createRowValues(findings []shTypes.AwsSecurityFinding) (values [][]interface{}) {
-	values = append(values, columnNames)
-
-	for _, e := range findings {
-		findingId := *e.Id
-		severity := e.Severity.Label
-		title := *e.Title
-		resourceId := *e.Resources[0].Id
-		workFlowStatus := e.Workflow.Status
-		productName := e.ProductName
-		region := *e.Region
-		awsAccountID := *e.AwsAccountId
-		createdDate := strings.Split(*e.CreatedAt, "T")[0]
-		updatedDate := strings.Split(*e.UpdatedAt, "T")[0]
-		createdAt := createdDate
-		updatedAt := updatedDate
-
-		values = append(values, []interface{}{
-			findingId,
-			severity,
-			title,
-			resourceId,
-			workFlowStatus,
-			productName,
-			region,
-			awsAccountID,
-			createdAt,
-			updatedAt,
+// collapseRequests returns the requests to group the consecutive collapsed columns and collapse the groups.
+// Columns already in the existing groups are skipped, since adding a group again nests it.
+func collapseRequests(sheetId int64, columns []report.Column, groups []*sheets.DimensionGroup) []*sheets.Request {
+	grouped := func(i int) bool {
+		return slices.ContainsFunc(groups, func(g *sheets.DimensionGroup) bool {
+			return g.Range.StartIndex <= int64(i) && int64(i) < g.Range.EndIndex
 		})
 	}
-	return
-}
 
-func createUriToFinding(findingID string, region string) string {
-	fstEncoding := url.QueryEscape(prefix + findingID)
-	sndEncoding := url.QueryEscape(fstEncoding)
-	return fmt.Sprintf(base, region) + sndEncoding
+	requests := make([]*sheets.Request, 0)
+	for start := 0; start < len(columns); start++ {
+		if !columns[start].Collapsed || grouped(start) {
+			continue
+		}
+		end := start + 1
+		for end < len(columns) && columns[end].Collapsed && !grouped(end) {
+			end++
+		}
+		dimensions := &sheets.DimensionRange{
+			SheetId:    sheetId,
+			Dimension:  "COLUMNS",
+			StartIndex: int64(start),
+			EndIndex:   int64(end),
+		}
+		requests = append(requests,
+			&sheets.Request{
+				AddDimensionGroup: &sheets.AddDimensionGroupRequest{Range: dimensions},
+			},
+			&sheets.Request{
+				UpdateDimensionGroup: &sheets.UpdateDimensionGroupRequest{
+					DimensionGroup: &sheets.DimensionGroup{Range: dimensions, Depth: 1, Collapsed: true},
+					Fields:         "collapsed",
+				},
+			},
+		)
+		start = end
+	}
+	return requests
 }
@@ -0,0 +1,55 @@
+package sheet
+
+import (
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/sheets/v4"
+	"testing"
+)
+
+func TestLinkRequests(t *testing.T) {
+	link := func(shTypes.AwsSecurityFinding) string { return "" }
+	columns := []report.Column{{Name: "ID", Link: link}, {Name: "Title"}, {Name: "Remediation", Link: link}}
+	rows := []syncRow{
+		{links: []string{"a", "", "https://example.com/a"}},
+		{links: []string{"b", "", ""}},
+		{resolved: true},
+		{blank: true},
+		{links: []string{"c", "", ""}},
+	}
+	requests := linkRequests(1, columns, rows)
+
+	// Runs of the active rows for each column with links
+	assert.Len(t, requests, 4)
+	first := requests[0].UpdateCells
+	assert.Equal(t, int64(1), first.Range.StartRowIndex)
+	assert.Equal(t, int64(3), first.Range.EndRowIndex)
+	assert.Equal(t, int64(0), first.Range.StartColumnIndex)
+	assert.Equal(t, "a", first.Rows[0].Values[0].UserEnteredFormat.TextFormat.Link.Uri)
+	assert.Equal(t, int64(5), requests[1].UpdateCells.Range.StartRowIndex)
+	assert.Equal(t, int64(6), requests[1].UpdateCells.Range.EndRowIndex)
+	remediation := requests[2].UpdateCells
+	assert.Equal(t, int64(2), remediation.Range.StartColumnIndex)
+	assert.Equal(t, "https://example.com/a", remediation.Rows[0].Values[0].UserEnteredFormat.TextFormat.Link.Uri)
+	// Empty links are cleared
+	assert.Nil(t, remediation.Rows[1].Values[0].UserEnteredFormat.TextFormat.Link)
+}
+
+func TestCollapseRequests(t *testing.T) {
+	columns := []report.Column{{Name: "ID"}, {Collapsed: true}, {Collapsed: true}, {}, {Collapsed: true}}
+
+	requests := collapseRequests(1, columns, nil)
+	assert.Len(t, requests, 4)
+	assert.Equal(t, int64(1), requests[0].AddDimensionGroup.Range.StartIndex)
+	assert.Equal(t, int64(3), requests[0].AddDimensionGroup.Range.EndIndex)
+	assert.True(t, requests[1].UpdateDimensionGroup.DimensionGroup.Collapsed)
+	assert.Equal(t, int64(4), requests[2].AddDimensionGroup.Range.StartIndex)
+	assert.Equal(t, int64(5), requests[2].AddDimensionGroup.Range.EndIndex)
+
+	// Columns already grouped are not grouped again
+	groups := []*sheets.DimensionGroup{{Range: &sheets.DimensionRange{StartIndex: 1, EndIndex: 3}}}
+	requests = collapseRequests(1, columns, groups)
+	assert.Len(t, requests, 2)
+	assert.Equal(t, int64(4), requests[0].AddDimensionGroup.Range.StartIndex)
+}
@@ -2,9 +2,11 @@ package sheet
 
 import (
 	"errors"
+	"fmt"
 	"github.com/avast/retry-go/v4"
 	"google.golang.org/api/googleapi"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -24,3 +26,22 @@ func Retry[T any](fn retry.RetryableFuncWithData[T]) (T, error) {
 		retry.Attempts(10),
 	)
 }
+
+// a1Range returns the A1 notation of the range in the sheet, quoting the sheet title
+func a1Range(title string, rng string) string {
+	return fmt.Sprintf("'%s'!%s", strings.ReplaceAll(title, "'", "''"), rng)
+}
+
+// sheetRange returns the A1 notation of the whole used range of the sheet
+func sheetRange(title string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(title, "'", "''"))
+}
+
+// columnLetter returns the column letter of the 0-based column index, such as 'A', 'Z' and 'AA'
+func columnLetter(index int) string {
+	letter := ""
+	for n := index + 1; n > 0; n = (n - 1) / 26 {
+		letter = string(rune('A'+(n-1)%26)) + letter
+	}
+	return letter
+}
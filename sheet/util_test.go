@@ -0,0 +1,114 @@
+package sheet
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestColumnLetter(t *testing.T) {
+	tests := []struct {
+		index    int
+		expected string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, columnLetter(tt.index), tt.index)
+	}
+}
+
+func TestA1Range(t *testing.T) {
+	assert.Equal(t, "'Insight: public'!A1", a1Range("Insight: public", "A1"))
+	assert.Equal(t, "'Bob''s team'!A:C", a1Range("Bob's team", "A:C"))
+	assert.Equal(t, "'Bob''s team'", sheetRange("Bob's team"))
+}
+
+// fakeSpreadsheet serves the Sheets API for the sheets and their values in memory,
+// and records the values written by the exporter
+type fakeSpreadsheet struct {
+	sheets []*sheets.Sheet
+	values map[string][][]interface{}
+	// Values written by the update requests, by A1 range
+	written map[string][][]interface{}
+}
+
+func newFakeSpreadSheet(t *testing.T, sheetz []*sheets.Sheet, values map[string][][]interface{}) (SecurityHubSpreadSheet, *fakeSpreadsheet) {
+	fake := &fakeSpreadsheet{sheets: sheetz, values: values, written: make(map[string][][]interface{}, 0)}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	service, err := sheets.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	assert.NoError(t, err)
+	return SecurityHubSpreadSheet{Service: service, Spreadsheet: &sheets.Spreadsheet{SpreadsheetId: "fake"}}, fake
+}
+
+// rangeTitle returns the sheet title of the A1 range
+func rangeTitle(rng string) string {
+	title, _, _ := strings.Cut(rng, "!")
+	return strings.ReplaceAll(strings.Trim(title, "'"), "''", "'")
+}
+
+func (f *fakeSpreadsheet) serve(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, "/v4/spreadsheets/fake")
+	var res interface{} = struct{}{}
+	switch {
+	case req.Method == http.MethodGet && path == "":
+		res = sheets.Spreadsheet{Sheets: f.sheets}
+	case req.Method == http.MethodGet && path == "/values:batchGet":
+		ranges := make([]*sheets.ValueRange, 0)
+		for _, rng := range req.URL.Query()["ranges"] {
+			ranges = append(ranges, &sheets.ValueRange{Range: rng, Values: f.values[rangeTitle(rng)]})
+		}
+		res = sheets.BatchGetValuesResponse{ValueRanges: ranges}
+	case req.Method == http.MethodGet:
+		res = sheets.ValueRange{Values: f.values[rangeTitle(strings.TrimPrefix(path, "/values/"))]}
+	case req.Method == http.MethodPut:
+		var body sheets.ValueRange
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		f.written[strings.TrimPrefix(path, "/values/")] = body.Values
+	case path == "/values:batchUpdate":
+		var body sheets.BatchUpdateValuesRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		for _, vr := range body.Data {
+			f.written[vr.Range] = vr.Values
+		}
+	case path == ":batchUpdate":
+		var body sheets.BatchUpdateSpreadsheetRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		replies := make([]*sheets.Response, 0)
+		for _, r := range body.Requests {
+			reply := &sheets.Response{}
+			if r.AddSheet != nil {
+				props := r.AddSheet.Properties
+				props.SheetId = int64(len(f.sheets) + 100)
+				f.sheets = append(f.sheets, &sheets.Sheet{Properties: props})
+				reply.AddSheet = &sheets.AddSheetResponse{Properties: props}
+			}
+			if r.DeleteSheet != nil {
+				f.sheets = slices.DeleteFunc(f.sheets, func(s *sheets.Sheet) bool {
+					return s.Properties.SheetId == r.DeleteSheet.SheetId
+				})
+			}
+			replies = append(replies, reply)
+		}
+		res = sheets.BatchUpdateSpreadsheetResponse{Replies: replies}
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
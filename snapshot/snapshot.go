@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot is the findings enriched with the resource tags, saved to replay the export without AWS
+type Snapshot struct {
+	// Time when the findings were fetched, zero if unknown like the findings loaded from an ASFF file
+	FetchedAt time.Time
+	// Version of the exporter which saved the snapshot
+	Version  string
+	Findings []types.AwsSecurityFinding
+	// Custom insights with their findings
+	Insights []aws.Insight
+	// Accounts in the organization by account ID, if AWS Organizations is used
+	Accounts map[string]aws.OrganizationAccount
+}
+
+// Save writes the snapshot to the file as gzip-compressed JSON
+func Save(path string, snapshot Snapshot) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := gzip.NewWriter(file)
+	err = json.NewEncoder(w).Encode(snapshot)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads the snapshot from the file written by Save
+func Load(path string) (*Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var ret Snapshot
+	err = json.NewDecoder(r).Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
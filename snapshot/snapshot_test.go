@@ -0,0 +1,38 @@
+package snapshot
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	id := "id-1"
+	resourceId := "arn:aws:s3:::bucket"
+	fetchedAt := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "snapshot.json.gz")
+
+	err := Save(path, Snapshot{
+		FetchedAt: fetchedAt,
+		Version:   "v1.0.0",
+		Findings: []types.AwsSecurityFinding{
+			{
+				Id:        &id,
+				Severity:  &types.Severity{Label: types.SeverityLabelHigh, Normalized: 70},
+				Resources: []types.Resource{{Id: &resourceId, Tags: map[string]string{"Project": "foo"}}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	s, err := Load(path)
+	assert.NoError(t, err)
+	assert.True(t, fetchedAt.Equal(s.FetchedAt))
+	assert.Equal(t, "v1.0.0", s.Version)
+	assert.Len(t, s.Findings, 1)
+	assert.Equal(t, "id-1", *s.Findings[0].Id)
+	assert.Equal(t, types.SeverityLabelHigh, s.Findings[0].Severity.Label)
+	assert.Equal(t, "foo", s.Findings[0].Resources[0].Tags["Project"])
+}
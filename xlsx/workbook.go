@@ -0,0 +1,331 @@
+package xlsx
+
+import (
+	"context"
+	"fmt"
+	shTypes "github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/xuri/excelize/v2"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Characters not allowed in the worksheet names
+var invalidSheetNameChars = strings.NewReplacer(
+	":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_",
+)
+
+const maxSheetNameLength = 31
+
+// SecurityHubWorkbook exports the findings to a local XLSX file in the same layout as the spreadsheet
+type SecurityHubWorkbook struct {
+	Path           string
+	Severities     []aws.Severity
+	Colors         map[string]string
+	IndexSheetName string
+	// Columns of the rows of findings
+	Columns []report.Column
+}
+
+func NewWorkbook(config cfg.Config, columns []report.Column, path string) (*SecurityHubWorkbook, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path of the xlsx output is required")
+	}
+
+	ret := &SecurityHubWorkbook{
+		Path:           path,
+		Severities:     config.Severities,
+		Colors:         make(map[string]string, 0),
+		IndexSheetName: config.IndexSheetName,
+		Columns:        columns,
+	}
+	for k, v := range config.Colors {
+		c, err := report.ParseColor(v)
+		if err != nil {
+			return nil, err
+		}
+		ret.Colors[strings.ToUpper(string(k))] = fmt.Sprintf("%02X%02X%02X", c.R, c.G, c.B)
+	}
+	return ret, nil
+}
+
+func (w SecurityHubWorkbook) Name() string {
+	return fmt.Sprintf("XLSX workbook '%s'", w.Path)
+}
+
+// Export writes the index sheet and the project sheets to the file
+func (w SecurityHubWorkbook) Export(_ context.Context, project2Findings exporter.Project2Findings, metadata exporter.Metadata) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	err := f.SetSheetName("Sheet1", w.IndexSheetName)
+	if err != nil {
+		return err
+	}
+
+	project2SheetName := make(map[string]string, 0)
+	sheetNames := map[string]bool{strings.ToLower(w.IndexSheetName): true}
+	for _, project := range report.SortedProjects(project2Findings) {
+		name := uniqueSheetName(project, sheetNames)
+		sheetNames[strings.ToLower(name)] = true
+		project2SheetName[project] = name
+
+		log.Printf("Writing sheet for '%s'...", project)
+		findings := project2Findings[project]
+		report.SortFindings(findings)
+		err = w.writeProjectSheet(f, name, findings)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.writeIndexSheet(f, project2Findings, project2SheetName, metadata.Insights)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(w.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return f.SaveAs(w.Path)
+}
+
+func (w SecurityHubWorkbook) writeIndexSheet(f *excelize.File, project2Findings exporter.Project2Findings, project2SheetName map[string]string, insights []aws.Insight) error {
+	header := []interface{}{"Project", "Findings"}
+	for _, s := range w.Severities {
+		header = append(header, string(s))
+	}
+	err := w.writeHeader(f, w.IndexSheetName, header)
+	if err != nil {
+		return err
+	}
+
+	linkStyle, err := f.NewStyle(&excelize.Style{Font: linkFont()})
+	if err != nil {
+		return err
+	}
+
+	rowNum := 2
+	for _, project := range report.SortedProjects(project2Findings) {
+		findings := project2Findings[project]
+		row := []interface{}{project, len(findings)}
+		for _, s := range w.Severities {
+			row = append(row, report.CountBySeverity(findings, s))
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+		err = f.SetSheetRow(w.IndexSheetName, cell, &row)
+		if err != nil {
+			return err
+		}
+		err = f.SetCellHyperLink(w.IndexSheetName, cell, sheetLocation(project2SheetName[project]), "Location")
+		if err != nil {
+			return err
+		}
+		err = f.SetCellStyle(w.IndexSheetName, cell, cell, linkStyle)
+		if err != nil {
+			return err
+		}
+		rowNum++
+	}
+
+	// Results of the insights grouped by their attributes
+	boldStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	boldLinkStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Color: "1155CC", Underline: "single"}})
+	if err != nil {
+		return err
+	}
+	for _, i := range insights {
+		// Empty row as a separator
+		rowNum++
+		row := []interface{}{i.Name, i.GroupByAttribute, "Count"}
+		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+		lastCell, _ := excelize.CoordinatesToCellName(len(row), rowNum)
+		err = f.SetSheetRow(w.IndexSheetName, cell, &row)
+		if err != nil {
+			return err
+		}
+		err = f.SetCellStyle(w.IndexSheetName, cell, lastCell, boldStyle)
+		if err != nil {
+			return err
+		}
+		if sheetName, ok := project2SheetName[exporter.InsightProjectName(i.Name)]; ok {
+			err = f.SetCellHyperLink(w.IndexSheetName, cell, sheetLocation(sheetName), "Location")
+			if err != nil {
+				return err
+			}
+			err = f.SetCellStyle(w.IndexSheetName, cell, cell, boldLinkStyle)
+			if err != nil {
+				return err
+			}
+		}
+		rowNum++
+
+		for _, result := range i.Results {
+			row := []interface{}{nil, result.Value, result.Count}
+			cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+			err = f.SetSheetRow(w.IndexSheetName, cell, &row)
+			if err != nil {
+				return err
+			}
+			rowNum++
+		}
+	}
+
+	return f.SetColWidth(w.IndexSheetName, "A", "A", 30)
+}
+
+func (w SecurityHubWorkbook) writeProjectSheet(f *excelize.File, name string, findings []shTypes.AwsSecurityFinding) error {
+	_, err := f.NewSheet(name)
+	if err != nil {
+		return err
+	}
+
+	values := report.CreateRowValues(w.Columns, findings)
+	err = w.writeHeader(f, name, values[0])
+	if err != nil {
+		return err
+	}
+
+	// Styles of the rows by severity, with and without the link
+	type styles struct{ row, link int }
+	severity2Styles := make(map[string]styles, 0)
+	for s, c := range w.Colors {
+		fill := excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{c}}
+		row, err := f.NewStyle(&excelize.Style{Fill: fill})
+		if err != nil {
+			return err
+		}
+		link, err := f.NewStyle(&excelize.Style{Fill: fill, Font: linkFont()})
+		if err != nil {
+			return err
+		}
+		severity2Styles[s] = styles{row: row, link: link}
+	}
+	defaultLinkStyle, err := f.NewStyle(&excelize.Style{Font: linkFont()})
+	if err != nil {
+		return err
+	}
+
+	lastColumn, _ := excelize.ColumnNumberToName(len(values[0]))
+	for i, finding := range findings {
+		row := values[i+1]
+		firstCell, _ := excelize.CoordinatesToCellName(1, i+2)
+		lastCell := fmt.Sprintf("%s%d", lastColumn, i+2)
+		err = f.SetSheetRow(name, firstCell, &row)
+		if err != nil {
+			return err
+		}
+
+		linkStyle := defaultLinkStyle
+		if s, ok := severity2Styles[string(finding.Severity.Label)]; ok {
+			err = f.SetCellStyle(name, firstCell, lastCell, s.row)
+			if err != nil {
+				return err
+			}
+			linkStyle = s.link
+		}
+		for column, link := range report.CreateRowLinks(w.Columns, finding) {
+			if link == "" {
+				continue
+			}
+			linkCell, _ := excelize.CoordinatesToCellName(column+1, i+2)
+			err = f.SetCellHyperLink(name, linkCell, link, "External")
+			if err != nil {
+				return err
+			}
+			err = f.SetCellStyle(name, linkCell, linkCell, linkStyle)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// XLSX cannot fit the widths to the values, so the columns are widened instead
+	for i, c := range w.Columns {
+		column, _ := excelize.ColumnNumberToName(i + 1)
+		if c.AutoResize {
+			err = f.SetColWidth(name, column, column, 60)
+			if err != nil {
+				return err
+			}
+		}
+		// Collapsed columns are hidden in the outline group
+		if c.Collapsed {
+			err = f.SetColOutlineLevel(name, column, 1)
+			if err != nil {
+				return err
+			}
+			err = f.SetColVisible(name, column, false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeHeader writes the bold header row and freezes it
+func (w SecurityHubWorkbook) writeHeader(f *excelize.File, name string, header []interface{}) error {
+	err := f.SetSheetRow(name, "A1", &header)
+	if err != nil {
+		return err
+	}
+	style, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	lastColumn, _ := excelize.ColumnNumberToName(len(header))
+	err = f.SetCellStyle(name, "A1", lastColumn+"1", style)
+	if err != nil {
+		return err
+	}
+	return f.SetPanes(name, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+}
+
+func linkFont() *excelize.Font {
+	return &excelize.Font{Color: "1155CC", Underline: "single"}
+}
+
+// sheetLocation returns the location of the first cell of the sheet for the internal links, quoting the sheet name
+func sheetLocation(name string) string {
+	return fmt.Sprintf("'%s'!A1", strings.ReplaceAll(name, "'", "''"))
+}
+
+// uniqueSheetName returns the worksheet name for the project, which is limited in length and characters.
+// The existing names must be in lower case since the names are case-insensitive.
+func uniqueSheetName(project string, existing map[string]bool) string {
+	name := []rune(invalidSheetNameChars.Replace(project))
+	if len(name) > maxSheetNameLength {
+		name = name[:maxSheetNameLength]
+	}
+	// Apostrophes are allowed only in the middle of the name
+	for _, i := range []int{0, len(name) - 1} {
+		if i >= 0 && name[i] == '\'' {
+			name[i] = '_'
+		}
+	}
+	ret := string(name)
+	for i := 2; existing[strings.ToLower(ret)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		n := name
+		if len(n)+len(suffix) > maxSheetNameLength {
+			n = n[:maxSheetNameLength-len(suffix)]
+		}
+		ret = string(n) + suffix
+	}
+	return ret
+}
@@ -0,0 +1,150 @@
+package xlsx
+
+import (
+	"context"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/kota65535/securityhub-exporter/aws"
+	"github.com/kota65535/securityhub-exporter/cfg"
+	"github.com/kota65535/securityhub-exporter/exporter"
+	"github.com/kota65535/securityhub-exporter/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"path/filepath"
+	"testing"
+)
+
+var normalizedSeverities = map[types.SeverityLabel]int32{
+	types.SeverityLabelCritical: 90,
+	types.SeverityLabelHigh:     70,
+}
+
+func newFinding(id string, severity types.SeverityLabel) types.AwsSecurityFinding {
+	region := "ap-northeast-1"
+	accountId := "111111111111"
+	title := "title of " + id
+	resourceId := "arn:aws:s3:::bucket"
+	timestamp := "2023-08-01T00:00:00.000Z"
+	productName := "Security Hub"
+	return types.AwsSecurityFinding{
+		Id:           &id,
+		ProductName:  &productName,
+		Title:        &title,
+		Region:       &region,
+		AwsAccountId: &accountId,
+		Severity:     &types.Severity{Label: severity, Normalized: normalizedSeverities[severity]},
+		Workflow:     &types.Workflow{Status: types.WorkflowStatusNew},
+		Resources:    []types.Resource{{Id: &resourceId}},
+		CreatedAt:    &timestamp,
+		UpdatedAt:    &timestamp,
+	}
+}
+
+func TestExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "findings.xlsx")
+	remediationColumn, _ := report.BuiltinColumn("Remediation", nil)
+	w, err := NewWorkbook(cfg.Config{
+		Severities:     []aws.Severity{aws.CRITICAL, aws.HIGH},
+		Colors:         map[aws.Severity]string{"critical": "#EA9999", "high": "orange"},
+		IndexSheetName: "Index",
+	}, append(report.DefaultColumns(), remediationColumn), path)
+	assert.NoError(t, err)
+
+	b := newFinding("b", types.SeverityLabelCritical)
+	b.Remediation = &types.Remediation{Recommendation: &types.Recommendation{
+		Text: awssdk.String("Enable the encryption"),
+		Url:  awssdk.String("https://docs.aws.amazon.com/securityhub/"),
+	}}
+	err = w.Export(context.Background(), exporter.Project2Findings{
+		"foo": {newFinding("a", types.SeverityLabelHigh), b},
+		"bar": {newFinding("c", types.SeverityLabelHigh)},
+	}, exporter.Metadata{})
+	assert.NoError(t, err)
+
+	f, err := excelize.OpenFile(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"Index", "bar", "foo"}, f.GetSheetList())
+
+	rows, err := f.GetRows("Index")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Project", "Findings", "CRITICAL", "HIGH"},
+		{"bar", "1", "0", "1"},
+		{"foo", "2", "1", "1"},
+	}, rows)
+
+	productName, err := f.GetCellValue("foo", "F2")
+	assert.NoError(t, err)
+	assert.Equal(t, "Security Hub", productName)
+
+	// Sorted by severity
+	id, err := f.GetCellValue("foo", "A2")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", id)
+	ok, link, err := f.GetCellHyperLink("foo", "A2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Contains(t, link, "console.aws.amazon.com/securityhub")
+
+	// Remediation linked to its URL
+	remediation, err := f.GetCellValue("foo", "K2")
+	assert.NoError(t, err)
+	assert.Equal(t, "Enable the encryption", remediation)
+	ok, link, err = f.GetCellHyperLink("foo", "K2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "https://docs.aws.amazon.com/securityhub/", link)
+	ok, _, err = f.GetCellHyperLink("foo", "K3")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestUniqueSheetName(t *testing.T) {
+	existing := map[string]bool{"index": true, "a_b": true}
+	assert.Equal(t, "Index (2)", uniqueSheetName("Index", existing))
+	assert.Equal(t, "a_b (2)", uniqueSheetName("a/b", existing))
+	assert.Equal(t, "0123456789012345678901234567890", uniqueSheetName("0123456789012345678901234567890123", existing))
+	assert.Equal(t, "Bob's team", uniqueSheetName("Bob's team", existing))
+	assert.Equal(t, "_team_", uniqueSheetName("'team'", existing))
+}
+
+func TestSheetLocation(t *testing.T) {
+	assert.Equal(t, "'foo'!A1", sheetLocation("foo"))
+	assert.Equal(t, "'Bob''s team'!A1", sheetLocation("Bob's team"))
+}
+
+func TestExportInsights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.xlsx")
+	w, err := NewWorkbook(cfg.Config{
+		Severities:     []aws.Severity{aws.HIGH},
+		IndexSheetName: "Index",
+	}, report.DefaultColumns(), path)
+	assert.NoError(t, err)
+
+	insight := aws.Insight{
+		Name:             "Public buckets",
+		GroupByAttribute: "ResourceId",
+		Results:          []aws.InsightResult{{Value: "arn:aws:s3:::bucket", Count: 1}},
+		Findings:         []types.AwsSecurityFinding{newFinding("a", types.SeverityLabelHigh)},
+	}
+	err = w.Export(context.Background(), exporter.Project2Findings{
+		exporter.InsightProjectName(insight.Name): insight.Findings,
+	}, exporter.Metadata{Insights: []aws.Insight{insight}})
+	assert.NoError(t, err)
+
+	f, err := excelize.OpenFile(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"Index", "Insight_ Public buckets"}, f.GetSheetList())
+
+	rows, err := f.GetRows("Index")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Project", "Findings", "HIGH"},
+		{"Insight: Public buckets", "1", "1"},
+		nil,
+		{"Public buckets", "ResourceId", "Count"},
+		{"", "arn:aws:s3:::bucket", "1"},
+	}, rows)
+}